@@ -4,56 +4,170 @@ import (
     "fmt"
     "io"
     "os"
+    "path/filepath"
+    "strings"
 
+    "lemur/artifact"
+    "lemur/ast"
+    "lemur/check"
     "lemur/eval"
     "lemur/lexer"
     "lemur/parser"
     "lemur/object"
-    "lemur/token"
+    "lemur/resolver"
+    "lemur/vm"
 )
 
 func EvalFromReader(in io.Reader) {
-    b, err := io.ReadAll(in)
-    if err != nil || len(b) == 0 { return }
-
-    input := string(b)
     env := object.CreateEnvironment()
-    runEval(input, env)
+    runEval(lexer.NewFromReader(in), env, evalProgram)
 }
 
-func EvalFromFile(fname string) {
-    b, err := os.ReadFile(fname)
-    if err != nil || len(b) == 0 { return }
+func EvalFromFile(fname string) { runFile(fname, evalProgram) }
 
-    input := string(b)
-    env := object.CreateEnvironment()
-    runEval(input, env)
+// RunFromFile runs the source file on the bytecode vm instead of the evaluator
+func RunFromFile(fname string) { runFile(fname, vm.Run) }
+
+func runFile(fname string, run func(ast.Program, *object.Environment) object.Object) {
+    f, err := os.Open(fname)
+    if err != nil { return }
+    defer f.Close()
+
+    path, err := filepath.Abs(fname)
+    if err != nil { path = fname }
+
+    env := object.CreateModuleEnvironment(path)
+    if filepath.Ext(fname) == artifact.Extension {
+        runArtifact(f, fname, env, run)
+        return
+    }
+
+    runEval(lexer.NewFromReader(f), env, run)
 }
 
-func runEval(input string, env *object.Environment) {
-    input = input + "\x00"
-    l := lexer.New(input)
+// runArtifact runs a program built with BuildFile, its source (if next to it) is
+// only read to warn that the artifact is out of date
+func runArtifact(r io.Reader, fname string, env *object.Environment, run func(ast.Program, *object.Environment) object.Object) {
+    a, err := artifact.Read(r)
+    if err != nil {
+        fmt.Printf("Failed to load %s: %s\n", fname, err)
+        return
+    }
+
+    src := strings.TrimSuffix(fname, artifact.Extension) + eval.ModuleExtension
+    if source, err := os.ReadFile(src); err == nil && !a.Matches(source) {
+        fmt.Printf("Warning: %s is out of date with %s\n", fname, src)
+    }
+    if len(a.Program) == 0 { return }
+
+    evaluated := run(a.Program, env)
+    fmt.Println(evaluated.String())
+}
+
+// BuildFile parses the source file and writes it to out as an artifact which can be
+// run without parsing, operations on literals are folded if fold is set
+func BuildFile(fname, out string, fold bool) bool {
+    source, err := os.ReadFile(fname)
+    if err != nil {
+        fmt.Printf("Failed to open %s: %s\n", fname, err)
+        return false
+    }
+
+    p := parser.New(lexer.New(string(source)))
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0  {
+        printParserErrors(p.Errors())
+        return false
+    }
+
+    a := artifact.New(program, source)
+    if fold {
+        artifact.Fold(program)
+        a.Folded = true
+    }
+
+    f, err := os.Create(out)
+    if err == nil {
+        err = a.Write(f)
+        if cerr := f.Close(); err == nil { err = cerr }
+    }
+    if err != nil {
+        fmt.Printf("Failed to write %s: %s\n", out, err)
+        return false
+    }
+
+    return true
+}
+
+func evalProgram(program ast.Program, env *object.Environment) object.Object { return eval.Eval(program, env) }
+
+// CheckFile resolves and type checks the source file without running it, it prints
+// every diagnostic prefixed with the file name and reports whether there were no
+// errors (warnings are allowed)
+func CheckFile(fname string) bool {
+    f, err := os.Open(fname)
+    if err != nil {
+        fmt.Printf("Failed to open %s: %s\n", fname, err)
+        return false
+    }
+    defer f.Close()
+
+    l := lexer.NewFromReader(f)
     p := parser.New(l)
 
     program := p.ParseProgram()
+    if err := l.Err(); err != nil {
+        fmt.Printf("Failed to read input: %s\n", err)
+        return false
+    }
+    if len(p.Errors()) != 0  {
+        printParserErrors(p.Errors())
+        return false
+    }
+
+    ok := true
+    for _, d := range resolver.New(eval.BuiltinNames(), eval.PreludeNames()).Resolve(program) {
+        if d.Warning {
+            fmt.Printf("%s:%d:%d: warning: %s\n", fname, d.Line, d.Col, d.Message)
+            continue
+        }
+        fmt.Printf("%s:%s\n", fname, d)
+        ok = false
+    }
+
+    errors := check.Check(program)
+    for _, err := range errors {
+        fmt.Printf("%s:%s\n", fname, err)
+    }
+
+    return ok && len(errors) == 0
+}
+
+func runEval(l *lexer.Lexer, env *object.Environment, run func(ast.Program, *object.Environment) object.Object) {
+    p := parser.New(l)
+
+    program := p.ParseProgram()
+    if err := l.Err(); err != nil {
+        fmt.Printf("Failed to read input: %s\n", err)
+        return
+    }
     if len(p.Errors()) != 0  {
         printParserErrors(p.Errors())
         return
     }
+    if len(program) == 0 { return }
 
-    evaluated := eval.Eval(program, env)
+    evaluated := run(program, env)
     fmt.Println(evaluated.String())
 }
 
 func lex(input string) {
-    l := lexer.New(input)
-    for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+    for tok := range lexer.New(input).Tokens() {
         fmt.Printf("%+v\n", tok)
     }
 }
 
 func parse(input string, stringify bool) {
-    input = input + "\x00"
     l := lexer.New(input)
     p := parser.New(l)
 
@@ -6,6 +6,7 @@ import (
     "io"
     "strings"
 
+    "lemur/lexer"
     "lemur/object"
 )
 
@@ -65,7 +66,7 @@ func StartREPL(in io.Reader) {
         } else if mode == Stringify {
             parse(res, true)
         } else {
-            runEval(res, env)
+            runEval(lexer.New(res), env, evalProgram)
         }
     }
 }
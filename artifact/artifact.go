@@ -0,0 +1,284 @@
+// Package artifact stores parsed programs in a binary file so that they can be run
+// without lexing and parsing their source again
+package artifact
+
+import (
+    "crypto/sha256"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "reflect"
+
+    "lemur/ast"
+)
+
+const (
+    Magic     = "LEMC"
+    Extension = ".lemc"
+
+    // Version must be bumped whenever the encoding or the ast changes, artifacts of
+    // any other version are refused
+    Version = 2
+)
+
+const (
+    InvalidArtifactError = "invalid artifact"
+    VersionMismatchError = "unsupported artifact version"
+)
+
+const foldedFlag = 1
+
+// Artifact is a parsed program with the checksum of its source, the file starts with
+// Magic, the version, flags and the checksum followed by the encoded program
+type Artifact struct {
+    Version  uint16
+    Folded   bool
+    Checksum [sha256.Size]byte
+    Program  ast.Program
+}
+
+// nodeTypes lists every implementation of the ast interfaces, an interface value is
+// encoded as its index in this list (plus one, zero is nil)
+var nodeTypes = []reflect.Type{
+    reflect.TypeFor[*ast.BlockStatement](),
+    reflect.TypeFor[*ast.LetStatement](),
+    reflect.TypeFor[*ast.ReturnStatement](),
+    reflect.TypeFor[*ast.ThrowStatement](),
+    reflect.TypeFor[*ast.StructStatement](),
+    reflect.TypeFor[*ast.EnumStatement](),
+    reflect.TypeFor[*ast.ImportStatement](),
+    reflect.TypeFor[*ast.ExportStatement](),
+    reflect.TypeFor[*ast.ExpressionStatement](),
+
+    reflect.TypeFor[*ast.Identifier](),
+    reflect.TypeFor[*ast.ArrayLiteral](),
+    reflect.TypeFor[*ast.IndexExpression](),
+    reflect.TypeFor[*ast.MemberExpression](),
+    reflect.TypeFor[*ast.SliceExpression](),
+    reflect.TypeFor[*ast.StringLiteral](),
+    reflect.TypeFor[*ast.IntegerLiteral](),
+    reflect.TypeFor[*ast.FloatLiteral](),
+    reflect.TypeFor[*ast.BooleanLiteral](),
+    reflect.TypeFor[*ast.NullLiteral](),
+    reflect.TypeFor[*ast.PrefixExpression](),
+    reflect.TypeFor[*ast.InfixExpression](),
+    reflect.TypeFor[*ast.ConditionalExpression](),
+    reflect.TypeFor[*ast.TryExpression](),
+    reflect.TypeFor[*ast.WithExpression](),
+    reflect.TypeFor[*ast.MatchExpression](),
+    reflect.TypeFor[*ast.FunctionLiteral](),
+    reflect.TypeFor[*ast.SpreadExpression](),
+    reflect.TypeFor[*ast.NamedArgument](),
+    reflect.TypeFor[*ast.CallExpression](),
+
+    reflect.TypeFor[*ast.WildcardPattern](),
+    reflect.TypeFor[*ast.LiteralPattern](),
+    reflect.TypeFor[*ast.ArrayPattern](),
+    reflect.TypeFor[*ast.VariantPattern](),
+
+    reflect.TypeFor[*ast.NamedType](),
+    reflect.TypeFor[*ast.ArrayType](),
+    reflect.TypeFor[*ast.FunctionType](),
+}
+
+var typeIndex = map[reflect.Type]int{}
+
+func init() {
+    for i, t := range nodeTypes { typeIndex[t] = i }
+}
+
+var errTruncated = errors.New(InvalidArtifactError + ": unexpected end of data")
+
+// New creates an artifact of program, parsed from source
+func New(program ast.Program, source []byte) *Artifact {
+    return &Artifact{Version: Version, Checksum: sha256.Sum256(source), Program: program}
+}
+
+// Matches reports whether the artifact was built from source
+func (a *Artifact) Matches(source []byte) bool { return a.Checksum == sha256.Sum256(source) }
+
+func (a *Artifact) Write(w io.Writer) error {
+    var flags byte
+    if a.Folded { flags |= foldedFlag }
+
+    buf := append([]byte(Magic), 0, 0, flags)
+    binary.BigEndian.PutUint16(buf[len(Magic):], a.Version)
+    buf = append(buf, a.Checksum[:]...)
+
+    e := &encoder{buf: buf}
+    if err := e.value(reflect.ValueOf(a.Program)); err != nil { return err }
+
+    _, err := w.Write(e.buf)
+    return err
+}
+
+// Read decodes an artifact, refusing files of another format or version
+func Read(r io.Reader) (*Artifact, error) {
+    data, err := io.ReadAll(r)
+    if err != nil { return nil, err }
+
+    header := len(Magic) + 3 + sha256.Size
+    if len(data) < header || string(data[:len(Magic)]) != Magic {
+        return nil, errors.New(InvalidArtifactError + ": not a lemur artifact")
+    }
+
+    a := &Artifact{Version: binary.BigEndian.Uint16(data[len(Magic):])}
+    if a.Version != Version {
+        return nil, fmt.Errorf("%s: %d (expected %d)", VersionMismatchError, a.Version, Version)
+    }
+
+    a.Folded = data[len(Magic) + 2] & foldedFlag != 0
+    copy(a.Checksum[:], data[len(Magic) + 3:])
+
+    d := &decoder{data: data[header:]}
+    if err := d.value(reflect.ValueOf(&a.Program).Elem()); err != nil { return nil, err }
+    if len(d.data) != 0 { return nil, errors.New(InvalidArtifactError + ": trailing data") }
+
+    return a, nil
+}
+
+// encoder writes the exported fields of ast nodes in order, nil pointers and slices
+// are kept apart from empty ones (e.g. a variant pattern without parentheses)
+type encoder struct {
+    buf []byte
+}
+
+func (e *encoder) uint(n uint64) { e.buf = binary.AppendUvarint(e.buf, n) }
+
+func (e *encoder) value(v reflect.Value) error {
+    switch v.Kind() {
+    case reflect.Interface:
+        if v.IsNil() {
+            e.uint(0)
+            return nil
+        }
+
+        i, ok := typeIndex[v.Elem().Type()]
+        if !ok { return fmt.Errorf("cannot encode %s", v.Elem().Type()) }
+
+        e.uint(uint64(i + 1))
+        return e.value(v.Elem().Elem())
+    case reflect.Pointer:
+        if v.IsNil() {
+            e.uint(0)
+            return nil
+        }
+
+        e.uint(1)
+        return e.value(v.Elem())
+    case reflect.Struct:
+        for i := range v.NumField() {
+            if !v.Type().Field(i).IsExported() { continue }
+            if err := e.value(v.Field(i)); err != nil { return err }
+        }
+    case reflect.Slice:
+        if v.IsNil() {
+            e.uint(0)
+            return nil
+        }
+
+        e.uint(uint64(v.Len() + 1))
+        for i := range v.Len() {
+            if err := e.value(v.Index(i)); err != nil { return err }
+        }
+    case reflect.String:
+        e.uint(uint64(v.Len()))
+        e.buf = append(e.buf, v.String()...)
+    case reflect.Bool:
+        if v.Bool() { e.uint(1) } else { e.uint(0) }
+    case reflect.Int, reflect.Int64:
+        e.buf = binary.AppendVarint(e.buf, v.Int())
+    case reflect.Float64:
+        e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+    default:
+        return fmt.Errorf("cannot encode %s", v.Type())
+    }
+
+    return nil
+}
+
+type decoder struct {
+    data []byte
+}
+
+func (d *decoder) uint() (uint64, error) {
+    n, read := binary.Uvarint(d.data)
+    if read <= 0 { return 0, errTruncated }
+
+    d.data = d.data[read:]
+    return n, nil
+}
+
+func (d *decoder) bytes(n uint64) ([]byte, error) {
+    if n > uint64(len(d.data)) { return nil, errTruncated }
+
+    b := d.data[:n]
+    d.data = d.data[n:]
+    return b, nil
+}
+
+// value decodes into v, which must be settable
+func (d *decoder) value(v reflect.Value) error {
+    switch v.Kind() {
+    case reflect.Interface:
+        tag, err := d.uint()
+        if err != nil || tag == 0 { return err }
+        if tag > uint64(len(nodeTypes)) { return fmt.Errorf("%s: unknown node %d", InvalidArtifactError, tag) }
+
+        t := nodeTypes[tag - 1]
+        if !t.Implements(v.Type()) { return fmt.Errorf("%s: %s is not a %s", InvalidArtifactError, t, v.Type()) }
+
+        node := reflect.New(t.Elem())
+        if err := d.value(node.Elem()); err != nil { return err }
+        v.Set(node)
+    case reflect.Pointer:
+        present, err := d.uint()
+        if err != nil || present == 0 { return err }
+
+        ptr := reflect.New(v.Type().Elem())
+        if err := d.value(ptr.Elem()); err != nil { return err }
+        v.Set(ptr)
+    case reflect.Struct:
+        for i := range v.NumField() {
+            if !v.Type().Field(i).IsExported() { continue }
+            if err := d.value(v.Field(i)); err != nil { return err }
+        }
+    case reflect.Slice:
+        n, err := d.uint()
+        if err != nil || n == 0 { return err }
+        if n - 1 > uint64(len(d.data)) { return errTruncated } // every element takes a byte at least
+
+        s := reflect.MakeSlice(v.Type(), int(n - 1), int(n - 1))
+        for i := range s.Len() {
+            if err := d.value(s.Index(i)); err != nil { return err }
+        }
+        v.Set(s)
+    case reflect.String:
+        n, err := d.uint()
+        if err != nil { return err }
+
+        b, err := d.bytes(n)
+        if err != nil { return err }
+        v.SetString(string(b))
+    case reflect.Bool:
+        n, err := d.uint()
+        if err != nil { return err }
+        v.SetBool(n != 0)
+    case reflect.Int, reflect.Int64:
+        n, read := binary.Varint(d.data)
+        if read <= 0 { return errTruncated }
+
+        d.data = d.data[read:]
+        v.SetInt(n)
+    case reflect.Float64:
+        b, err := d.bytes(8)
+        if err != nil { return err }
+        v.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(b)))
+    default:
+        return fmt.Errorf("cannot decode %s", v.Type())
+    }
+
+    return nil
+}
@@ -0,0 +1,158 @@
+package artifact
+
+import (
+    "bytes"
+    "reflect"
+    "testing"
+
+    "lemur/ast"
+    "lemur/eval"
+    "lemur/lexer"
+    "lemur/object"
+    "lemur/parser"
+    "lemur/vm"
+)
+
+func TestRoundTrip(t *testing.T) {
+    tests := []string{
+        "let x = 5; let y: [int] = [1, 2.5, \"s\", true, null]; x",
+        "const [a, ..rest] = [1, 2, 3]; a + len(rest)",
+        "let f = fn(a: int, [b, c], d = 2, ...xs) -> int { return a * -b }; f(1, [2, 3], ...[5], d: 4)",
+        "let g: fn(int) -> bool = fn(n) { !(n > 2) && n != 1 || n == 0 }",
+        "if (x < 1) { 1 } else { x?[0] ?? x[1:2:-1] }; a?[::2]",
+        "struct P { x, y }; let p = P(1, y: 2); p with { x: 3 }; p.x",
+        "enum Shape { Circle(r), Rect(w, h), Empty, Unit() }; Shape.Circle(1)",
+        "match s { Shape.Circle(r) if r > 0 => r, Shape.Empty => 0, [1, _, ..rest] => { rest }, \"s\" => 1, None => 2 }",
+        "try { throw \"e\" } catch e { e.message } finally { 1 }; try { 1 } catch { 2 }",
+        "import \"lib/util\" as u; export let z = u.f(1) |> map(fn(x) { x })",
+        "",
+    }
+
+    for i, input := range tests {
+        program := runParse(t, input)
+        a := New(program, []byte(input))
+
+        var buf bytes.Buffer
+        if err := a.Write(&buf); err != nil { t.Fatalf("test %d: %s", i + 1, err) }
+
+        res, err := Read(&buf)
+        if err != nil { t.Fatalf("test %d: %s", i + 1, err) }
+
+        assert(t, i, res.Version, uint16(Version))
+        assert(t, i, res.Matches([]byte(input)), true)
+        assert(t, i, res.Matches([]byte(input + " ")), false)
+        assert(t, i, res.Program.String(), program.String())
+        if !reflect.DeepEqual(res.Program, program) {
+            t.Errorf("test %d: decoded program differs\n%s\n%s", i + 1, res.Program.PrintAST(), program.PrintAST())
+        }
+    }
+}
+
+func TestReadErrors(t *testing.T) {
+    var buf bytes.Buffer
+    New(runParse(t, "let x = [1, 2]; x"), nil).Write(&buf)
+    valid := buf.Bytes()
+
+    version := bytes.Clone(valid)
+    version[len(Magic) + 1]++
+
+    tests := []struct{
+        data     []byte
+        expected string
+    }{
+        {[]byte("let x = 1"), InvalidArtifactError + ": not a lemur artifact"},
+        {version, VersionMismatchError + ": 3 (expected 2)"},
+        {valid[:len(valid) - 3], InvalidArtifactError + ": unexpected end of data"},
+        {append(bytes.Clone(valid), 0), InvalidArtifactError + ": trailing data"},
+    }
+
+    for i, tst := range tests {
+        _, err := Read(bytes.NewReader(tst.data))
+        if err == nil { t.Fatalf("test %d: expected an error", i + 1) }
+
+        assert(t, i, err.Error(), tst.expected)
+    }
+}
+
+func TestFold(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected string // the folded value, empty if the operation is kept
+    }{
+        {"60 * 60 * 24", "86400"},
+        {"1 + 2.5", "3.5"},
+        {"\"a\" + \"b\" + \"c\"", "abc"},
+        {"-(2 * 3)", "-6"},
+        {"!(1 < 2) || 3 == 3", "true"},
+        // failing operations are kept to fail at run time
+        {"1 / 0", ""},
+        {"1 + \"a\"", ""},
+        {"1..3", ""},
+    }
+
+    for i, tst := range tests {
+        program := runParse(t, tst.input)
+        Fold(program)
+
+        // folded literals print as the operation they replace
+        assert(t, i, program.String(), runParse(t, tst.input).String())
+
+        val := literalValue(program[0].(*ast.ExpressionStatement).Value)
+        if tst.expected == "" {
+            assert(t, i, val, object.Object(nil))
+            continue
+        }
+        if val == nil { t.Fatalf("test %d: %s was not folded", i + 1, tst.input) }
+        assert(t, i, val.String(), tst.expected)
+    }
+
+    program := runParse(t, "fn(x = 2 * 3) { 1 + 1 }")
+    Fold(program)
+
+    fl := program[0].(*ast.ExpressionStatement).Value.(*ast.FunctionLiteral)
+    assert(t, 0, literalValue(fl.Parameters[0].Default).String(), "6")
+    assert(t, 0, literalValue(fl.Body.Statements[0].(*ast.ExpressionStatement).Value).String(), "2")
+}
+
+// folding does not change what a program evaluates to, its errors included
+func TestFoldEval(t *testing.T) {
+    tests := []string{
+        "let a = 2 * 3; [a + 1 * 2, 2 > 1, \"x\" + \"y\"]",
+        "let x = [1, 2]; x[5 - 4]",
+        "let f = fn(x = 2 * 3) { [x + 1 * 2] }; [f, f()]",
+        "fn() { 2 * 3 }",
+        "1 + \"a\"",
+        "let g = if (1 + 1) { 2 }",
+        "(2 * 3)()",
+        "[1, 2][-(1 + 1) * 2]",
+        "let [a] = 1 + 1",
+        "match 1 + 1 { 3 => 0 }",
+        "throw \"a\" + \"b\"",
+    }
+
+    for i, input := range tests {
+        expected := eval.Eval(runParse(t, input), object.CreateEnvironment())
+
+        folded := runParse(t, input)
+        Fold(folded)
+        assert(t, i, eval.Eval(folded, object.CreateEnvironment()).String(), expected.String())
+        assert(t, i, vm.Run(folded, object.CreateEnvironment()).String(), expected.String())
+    }
+}
+
+func runParse(t *testing.T, input string) ast.Program {
+    p := parser.New(lexer.New(input))
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0 { t.Fatalf("%q: %v", input, p.Errors()) }
+
+    return program
+}
+
+func assert(t *testing.T, testIdx int, val, expected any) {
+    if val != expected {
+        t.Errorf("test %d: incorrect value, expected %T: %v (got %T: %v)",
+            testIdx + 1,
+            expected, expected,
+            val, val)
+    }
+}
@@ -0,0 +1,101 @@
+package artifact
+
+import (
+    "reflect"
+
+    "lemur/ast"
+    "lemur/eval"
+    "lemur/object"
+    "lemur/token"
+)
+
+// Fold replaces infix and prefix operations on literals by the literal the evaluator
+// would produce, operations which fail (or do not produce a literal) are kept so
+// that they still fail with their position when the program runs, folded literals
+// print as the operation they replace so that errors and functions read the same
+func Fold(program ast.Program) {
+    fold(reflect.ValueOf(program))
+}
+
+func fold(v reflect.Value) {
+    switch v.Kind() {
+    case reflect.Interface:
+        if v.IsNil() { return }
+        fold(v.Elem())
+
+        exp, ok := v.Interface().(ast.Expression)
+        if !ok { return }
+
+        if lit := foldExpression(exp); lit != nil && reflect.TypeOf(lit).AssignableTo(v.Type()) {
+            v.Set(reflect.ValueOf(lit))
+        }
+    case reflect.Pointer:
+        if !v.IsNil() { fold(v.Elem()) }
+    case reflect.Struct:
+        for i := range v.NumField() {
+            if v.Type().Field(i).IsExported() { fold(v.Field(i)) }
+        }
+    case reflect.Slice:
+        for i := range v.Len() { fold(v.Index(i)) }
+    }
+}
+
+// foldExpression returns the literal exp evaluates to, keeping the position and
+// text of exp, or nil if exp is not an operation on literals
+func foldExpression(exp ast.Expression) ast.Expression {
+    var obj object.Object
+    var tok token.Token
+
+    switch exp := exp.(type) {
+    case *ast.InfixExpression:
+        left, right := literalValue(exp.Left), literalValue(exp.Right)
+        if left == nil || right == nil || exp.Operator == "??" { return nil }
+
+        // integer division by zero is left to fail when run
+        if n, ok := right.(*object.Integer); ok && n.Value == 0 && exp.Operator == "/" { return nil }
+
+        obj, tok = eval.Infix(exp.Operator, left, right), exp.Token
+    case *ast.PrefixExpression:
+        right := literalValue(exp.Right)
+        if right == nil { return nil }
+
+        obj, tok = eval.Prefix(exp.Operator, right), exp.Token
+    default:
+        return nil
+    }
+
+    tok.Literal = exp.String()
+    switch obj := obj.(type) {
+    case *object.Integer:
+        tok.Type = token.Int
+        return &ast.IntegerLiteral{Token: tok, Value: obj.Value}
+    case *object.Float:
+        tok.Type = token.Float
+        return &ast.FloatLiteral{Token: tok, Value: obj.Value}
+    case *object.String:
+        tok.Type = token.String
+        return &ast.StringLiteral{Token: tok, Value: obj.Value}
+    case *object.Boolean:
+        tok.Type = token.False
+        if obj.Value { tok.Type = token.True }
+        return &ast.BooleanLiteral{Token: tok, Value: obj.Value}
+    default:
+        return nil
+    }
+}
+
+func literalValue(exp ast.Expression) object.Object {
+    switch exp := exp.(type) {
+    case *ast.IntegerLiteral:
+        return &object.Integer{Value: exp.Value}
+    case *ast.FloatLiteral:
+        return &object.Float{Value: exp.Value}
+    case *ast.StringLiteral:
+        return &object.String{Value: exp.Value}
+    case *ast.BooleanLiteral:
+        if exp.Value { return eval.True }
+        return eval.False
+    default:
+        return nil
+    }
+}
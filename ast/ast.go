@@ -2,6 +2,7 @@ package ast
 
 import (
     "fmt"
+    "path/filepath"
     "reflect"
     "strings"
 
@@ -10,6 +11,7 @@ import (
 
 type Node interface {
     String() string
+    Pos() token.Token // the token the node was parsed from, zero if it has none
 }
 
 type Statement interface {
@@ -34,6 +36,7 @@ func (p Program) String() string {
     }
     return out.String()
 }
+func (p Program) Pos() token.Token { return token.Token{} }
 func (p Program) PrintAST() string {
     var b strings.Builder
 
@@ -91,6 +94,7 @@ type BlockStatement struct {
 var _ Statement = (*BlockStatement)(nil)
 
 func (bs *BlockStatement) _stmtNode(){}
+func (bs *BlockStatement) Pos() token.Token { return bs.Token }
 func (bs *BlockStatement) String() string {
     var out strings.Builder
 
@@ -103,20 +107,31 @@ func (bs *BlockStatement) String() string {
     return out.String()
 }
 
+// LetStatement binds Value to Name, or destructures it with Pattern when Name is nil,
+// it is also used for const declarations (distinguished by the token)
 type LetStatement struct {
     Token token.Token
     Name *Identifier
+    Pattern Pattern
+    Type TypeExpr // optional annotation
     Value Expression
 }
 var _ Statement = (*LetStatement)(nil)
 
 func (ls *LetStatement) _stmtNode(){}
+func (ls *LetStatement) Pos() token.Token { return ls.Token }
+func (ls *LetStatement) IsConst() bool { return ls.Token.Type == token.Const }
 func (ls *LetStatement) String() string {
     var out strings.Builder
 
     out.WriteString(ls.Token.Literal)
     out.WriteString(" ")
-    out.WriteString(ls.Name.String())
+    if ls.Name != nil {
+        out.WriteString(ls.Name.String())
+    } else {
+        out.WriteString(ls.Pattern.String())
+    }
+    if ls.Type != nil { out.WriteString(": " + ls.Type.String()) }
     out.WriteString(" = ")
     out.WriteString(ls.Value.String())
     out.WriteString(";")
@@ -131,6 +146,7 @@ type ReturnStatement struct {
 var _ Statement = (*ReturnStatement)(nil)
 
 func (rs *ReturnStatement) _stmtNode(){}
+func (rs *ReturnStatement) Pos() token.Token { return rs.Token }
 func (rs *ReturnStatement) String() string {
     var out strings.Builder
 
@@ -141,6 +157,140 @@ func (rs *ReturnStatement) String() string {
     return out.String()
 }
 
+type ThrowStatement struct {
+    Token token.Token
+    Value Expression
+}
+var _ Statement = (*ThrowStatement)(nil)
+
+func (ts *ThrowStatement) _stmtNode(){}
+func (ts *ThrowStatement) Pos() token.Token { return ts.Token }
+func (ts *ThrowStatement) String() string {
+    return ts.Token.Literal + " " + ts.Value.String() + ";"
+}
+
+// StructStatement declares a record type, binding its constructor to Name
+type StructStatement struct {
+    Token  token.Token
+    Name   *Identifier
+    Fields []*Identifier
+}
+var _ Statement = (*StructStatement)(nil)
+
+func (ss *StructStatement) _stmtNode(){}
+func (ss *StructStatement) Pos() token.Token { return ss.Token }
+func (ss *StructStatement) String() string {
+    fields := []string{}
+    for _, f := range ss.Fields {
+        fields = append(fields, f.String())
+    }
+
+    return ss.Token.Literal + " " + ss.Name.String() + " {" + strings.Join(fields, ", ") + "}"
+}
+
+// EnumStatement declares a tagged union, variants without fields are plain values
+type EnumStatement struct {
+    Token    token.Token
+    Name     *Identifier
+    Variants []*EnumVariant
+}
+var _ Statement = (*EnumStatement)(nil)
+
+func (es *EnumStatement) _stmtNode(){}
+func (es *EnumStatement) Pos() token.Token { return es.Token }
+func (es *EnumStatement) String() string {
+    variants := []string{}
+    for _, v := range es.Variants {
+        variants = append(variants, v.String())
+    }
+
+    return es.Token.Literal + " " + es.Name.String() + " {" + strings.Join(variants, ", ") + "}"
+}
+
+type EnumVariant struct {
+    Name   *Identifier
+    Fields []*Identifier
+}
+
+func (ev *EnumVariant) String() string {
+    if ev.Fields == nil { return ev.Name.String() }
+
+    fields := []string{}
+    for _, f := range ev.Fields {
+        fields = append(fields, f.String())
+    }
+
+    return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+type ImportStatement struct {
+    Token token.Token
+    Path  *StringLiteral
+    Alias *Identifier
+}
+var _ Statement = (*ImportStatement)(nil)
+
+func (is *ImportStatement) _stmtNode(){}
+func (is *ImportStatement) Pos() token.Token { return is.Token }
+func (is *ImportStatement) String() string {
+    var out strings.Builder
+
+    out.WriteString(is.Token.Literal + " ")
+    out.WriteString(fmt.Sprintf("%q", is.Path.Value))
+    if is.Alias != nil {
+        out.WriteString(" as ")
+        out.WriteString(is.Alias.String())
+    }
+    out.WriteString(";")
+
+    return out.String()
+}
+
+// Name is the identifier the module is bound to, the alias if present or else
+// the file name without its extension
+func (is *ImportStatement) Name() string {
+    if is.Alias != nil { return is.Alias.Value }
+
+    base := filepath.Base(is.Path.Value)
+    return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+type ExportStatement struct {
+    Token     token.Token
+    Statement Statement
+}
+var _ Statement = (*ExportStatement)(nil)
+
+func (es *ExportStatement) _stmtNode(){}
+func (es *ExportStatement) Pos() token.Token { return es.Token }
+func (es *ExportStatement) String() string {
+    return es.Token.Literal + " " + es.Statement.String()
+}
+
+// DeclaredNames lists the names a statement binds in its scope
+func DeclaredNames(stmt Statement) []string {
+    switch stmt := stmt.(type) {
+    case *LetStatement:
+        if stmt.Name != nil { return []string{stmt.Name.Value} }
+
+        names := []string{}
+        for _, ident := range Bindings(stmt.Pattern) {
+            names = append(names, ident.Value)
+        }
+        return names
+    case *StructStatement:
+        return []string{stmt.Name.Value}
+    case *EnumStatement:
+        return []string{stmt.Name.Value}
+    case *ImportStatement:
+        return []string{stmt.Name()}
+    case *ExportStatement:
+        return DeclaredNames(stmt.Statement)
+    default:
+        return nil
+    }
+}
+
 type ExpressionStatement struct {
     Token token.Token
     Value Expression
@@ -148,6 +298,7 @@ type ExpressionStatement struct {
 var _ Statement = (*ExpressionStatement)(nil);
 
 func (es *ExpressionStatement) _stmtNode(){}
+func (es *ExpressionStatement) Pos() token.Token { return es.Token }
 func (es *ExpressionStatement) String() string {
     var out strings.Builder
 
@@ -160,10 +311,17 @@ func (es *ExpressionStatement) String() string {
 type Identifier struct {
     Token token.Token
     Value string
+
+    // set by the resolver for local variables, which are stored at index Slot of the
+    // environment Depth levels out (other names are looked up by name)
+    Local bool
+    Depth int
+    Slot  int
 }
 var _ Expression = (*Identifier)(nil)
 
 func (i *Identifier) _exprNode(){}
+func (i *Identifier) Pos() token.Token { return i.Token }
 func (i *Identifier) String() string { return i.Value }
 
 type ArrayLiteral struct {
@@ -173,6 +331,7 @@ type ArrayLiteral struct {
 var _ Expression = (*ArrayLiteral)(nil)
 
 func (al *ArrayLiteral) _exprNode(){}
+func (al *ArrayLiteral) Pos() token.Token { return al.Token }
 func (al *ArrayLiteral) String() string {
     var out strings.Builder
 
@@ -189,18 +348,21 @@ func (al *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-    Token token.Token
-    Left  Expression
-    Index Expression
+    Token    token.Token
+    Left     Expression
+    Index    Expression
+    Optional bool
 }
 var _ Expression = (*IndexExpression)(nil)
 
 func (ie *IndexExpression) _exprNode(){}
+func (ie *IndexExpression) Pos() token.Token { return ie.Token }
 func (ie *IndexExpression) String() string {
     var out strings.Builder
 
     out.WriteString("(")
     out.WriteString(ie.Left.String())
+    if ie.Optional { out.WriteString("?") }
     out.WriteString("[")
     out.WriteString(ie.Index.String())
     out.WriteString("])")
@@ -208,6 +370,48 @@ func (ie *IndexExpression) String() string {
     return out.String()
 }
 
+type MemberExpression struct {
+    Token  token.Token
+    Left   Expression
+    Member *Identifier
+}
+var _ Expression = (*MemberExpression)(nil)
+
+func (me *MemberExpression) _exprNode(){}
+func (me *MemberExpression) Pos() token.Token { return me.Token }
+func (me *MemberExpression) String() string { return me.Left.String() + "." + me.Member.String() }
+
+type SliceExpression struct {
+    Token    token.Token
+    Left     Expression
+    Start    Expression
+    End      Expression
+    Step     Expression
+    Optional bool
+}
+var _ Expression = (*SliceExpression)(nil)
+
+func (se *SliceExpression) _exprNode(){}
+func (se *SliceExpression) Pos() token.Token { return se.Token }
+func (se *SliceExpression) String() string {
+    var out strings.Builder
+
+    out.WriteString("(")
+    out.WriteString(se.Left.String())
+    if se.Optional { out.WriteString("?") }
+    out.WriteString("[")
+    if se.Start != nil { out.WriteString(se.Start.String()) }
+    out.WriteString(":")
+    if se.End != nil { out.WriteString(se.End.String()) }
+    if se.Step != nil {
+        out.WriteString(":")
+        out.WriteString(se.Step.String())
+    }
+    out.WriteString("])")
+
+    return out.String()
+}
+
 type StringLiteral struct {
     Token token.Token
     Value string
@@ -215,6 +419,7 @@ type StringLiteral struct {
 var _ Expression = (*StringLiteral)(nil)
 
 func (sl *StringLiteral) _exprNode(){}
+func (sl *StringLiteral) Pos() token.Token { return sl.Token }
 func (sl *StringLiteral) String() string { return sl.Token.Literal }
 
 type IntegerLiteral struct {
@@ -224,8 +429,19 @@ type IntegerLiteral struct {
 var _ Expression = (*IntegerLiteral)(nil)
 
 func (il *IntegerLiteral) _exprNode(){}
+func (il *IntegerLiteral) Pos() token.Token { return il.Token }
 func (il *IntegerLiteral) String() string { return il.Token.Literal }
 
+type FloatLiteral struct {
+    Token token.Token
+    Value float64
+}
+var _ Expression = (*FloatLiteral)(nil)
+
+func (fl *FloatLiteral) _exprNode(){}
+func (fl *FloatLiteral) Pos() token.Token { return fl.Token }
+func (fl *FloatLiteral) String() string { return fl.Token.Literal }
+
 type BooleanLiteral struct {
     Token token.Token
     Value bool
@@ -233,8 +449,18 @@ type BooleanLiteral struct {
 var _ Expression = (*BooleanLiteral)(nil)
 
 func (b *BooleanLiteral) _exprNode(){}
+func (b *BooleanLiteral) Pos() token.Token { return b.Token }
 func (b *BooleanLiteral) String() string { return b.Token.Literal }
 
+type NullLiteral struct {
+    Token token.Token
+}
+var _ Expression = (*NullLiteral)(nil)
+
+func (n *NullLiteral) _exprNode(){}
+func (n *NullLiteral) Pos() token.Token { return n.Token }
+func (n *NullLiteral) String() string { return n.Token.Literal }
+
 type PrefixExpression struct {
     Token    token.Token
     Operator string
@@ -243,6 +469,7 @@ type PrefixExpression struct {
 var _ Expression = (*PrefixExpression)(nil)
 
 func (pe *PrefixExpression) _exprNode(){}
+func (pe *PrefixExpression) Pos() token.Token { return pe.Token }
 func (pe *PrefixExpression) String() string {
     var out strings.Builder
 
@@ -263,6 +490,7 @@ type InfixExpression struct {
 var _ Expression = (*InfixExpression)(nil)
 
 func (ie *InfixExpression) _exprNode(){}
+func (ie *InfixExpression) Pos() token.Token { return ie.Token }
 func (ie *InfixExpression) String() string {
     var out strings.Builder
 
@@ -286,6 +514,7 @@ type ConditionalExpression struct {
 var _ Expression = (*ConditionalExpression)(nil)
 
 func (ce *ConditionalExpression) _exprNode(){}
+func (ce *ConditionalExpression) Pos() token.Token { return ce.Token }
 func (ce *ConditionalExpression) String() string {
     var out strings.Builder
 
@@ -302,14 +531,145 @@ func (ce *ConditionalExpression) String() string {
     return out.String()
 }
 
+// TryExpression evaluates Body, running Catch (with the error bound to CatchName) if it
+// fails and Finally in either case, Catch and Finally are each optional but not both
+type TryExpression struct {
+    Token     token.Token
+    Body      *BlockStatement
+    CatchName *Identifier
+    Catch     *BlockStatement
+    Finally   *BlockStatement
+}
+var _ Expression = (*TryExpression)(nil)
+
+func (te *TryExpression) _exprNode(){}
+func (te *TryExpression) Pos() token.Token { return te.Token }
+func (te *TryExpression) String() string {
+    var out strings.Builder
+
+    out.WriteString("try ")
+    out.WriteString(te.Body.String())
+
+    if te.Catch != nil {
+        out.WriteString(" catch ")
+        if te.CatchName != nil { out.WriteString(te.CatchName.String() + " ") }
+        out.WriteString(te.Catch.String())
+    }
+    if te.Finally != nil {
+        out.WriteString(" finally ")
+        out.WriteString(te.Finally.String())
+    }
+
+    return out.String()
+}
+
+// WithExpression copies the record Left, replacing the fields in Updates
+type WithExpression struct {
+    Token   token.Token
+    Left    Expression
+    Updates []*FieldValue
+}
+var _ Expression = (*WithExpression)(nil)
+
+func (we *WithExpression) _exprNode(){}
+func (we *WithExpression) Pos() token.Token { return we.Token }
+func (we *WithExpression) String() string {
+    updates := []string{}
+    for _, u := range we.Updates {
+        updates = append(updates, u.String())
+    }
+
+    return "(" + we.Left.String() + " with {" + strings.Join(updates, ", ") + "})"
+}
+
+type FieldValue struct {
+    Name  *Identifier
+    Value Expression
+}
+
+func (fv *FieldValue) String() string { return fv.Name.String() + ": " + fv.Value.String() }
+
+type MatchExpression struct {
+    Token   token.Token
+    Subject Expression
+    Arms    []*MatchArm
+}
+var _ Expression = (*MatchExpression)(nil)
+
+func (me *MatchExpression) _exprNode(){}
+func (me *MatchExpression) Pos() token.Token { return me.Token }
+func (me *MatchExpression) String() string {
+    var out strings.Builder
+
+    arms := []string{}
+    for _, arm := range me.Arms {
+        arms = append(arms, arm.String())
+    }
+
+    out.WriteString("match ")
+    out.WriteString(me.Subject.String())
+    out.WriteString(" {")
+    out.WriteString(strings.Join(arms, ", "))
+    out.WriteString("}")
+
+    return out.String()
+}
+
+// MatchArm is a single 'pattern [if guard] => body' case, Body is either a block
+// or an expression statement
+type MatchArm struct {
+    Pattern Pattern
+    Guard   Expression
+    Body    Statement
+}
+
+func (ma *MatchArm) String() string {
+    var out strings.Builder
+
+    out.WriteString(ma.Pattern.String())
+    if ma.Guard != nil {
+        out.WriteString(" if ")
+        out.WriteString(ma.Guard.String())
+    }
+    out.WriteString(" => ")
+
+    if es, ok := ma.Body.(*ExpressionStatement); ok {
+        out.WriteString(es.Value.String())
+    } else {
+        out.WriteString(ma.Body.String())
+    }
+
+    return out.String()
+}
+
+// Parameter is a function parameter, Rest collects any remaining positional arguments
+type Parameter struct {
+    Token   token.Token
+    Pattern Pattern
+    Type    TypeExpr
+    Default Expression
+    Rest    bool
+}
+
+func (p *Parameter) String() string {
+    out := p.Pattern.String()
+    if p.Rest { out = "..." + out }
+    if p.Type != nil { out += ": " + p.Type.String() }
+    if p.Default != nil { out += " = " + p.Default.String() }
+
+    return out
+}
+
 type FunctionLiteral struct {
     Token	 token.Token
-    Parameters []*Identifier
+    Parameters []*Parameter
+    ReturnType TypeExpr
     Body	 *BlockStatement
 }
 var _ Expression = (*FunctionLiteral)(nil)
 
 func (fl *FunctionLiteral) _exprNode(){}
+func (fl *FunctionLiteral) Pos() token.Token { return fl.Token }
 func (fl *FunctionLiteral) String() string {
     var out strings.Builder
 
@@ -322,11 +682,34 @@ func (fl *FunctionLiteral) String() string {
     out.WriteString("(")
     out.WriteString(strings.Join(params, ", "))
     out.WriteString(")")
+    if fl.ReturnType != nil { out.WriteString(" -> " + fl.ReturnType.String() + " ") }
     out.WriteString(fl.Body.String())
 
     return out.String()
 }
 
+// SpreadExpression expands an iterable into positional call arguments ('f(...xs)')
+type SpreadExpression struct {
+    Token token.Token
+    Value Expression
+}
+var _ Expression = (*SpreadExpression)(nil)
+
+func (se *SpreadExpression) _exprNode(){}
+func (se *SpreadExpression) Pos() token.Token { return se.Token }
+func (se *SpreadExpression) String() string { return "..." + se.Value.String() }
+
+// NamedArgument is a keyword argument at a call site ('f(x: 1)')
+type NamedArgument struct {
+    Name  *Identifier
+    Value Expression
+}
+var _ Expression = (*NamedArgument)(nil)
+
+func (na *NamedArgument) _exprNode(){}
+func (na *NamedArgument) Pos() token.Token { return na.Name.Token }
+func (na *NamedArgument) String() string { return na.Name.String() + ": " + na.Value.String() }
+
 type CallExpression struct {
     Token token.Token
     Function Expression
@@ -334,6 +717,7 @@ type CallExpression struct {
 }
 
 func (ce *CallExpression) _exprNode(){}
+func (ce *CallExpression) Pos() token.Token { return ce.Token }
 func (ce *CallExpression) String() string {
     var out strings.Builder
 
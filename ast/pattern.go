@@ -0,0 +1,116 @@
+package ast
+
+import (
+    "strings"
+
+    "lemur/token"
+)
+
+// Pattern is the left hand side of a match arm, it is tested against a value
+// and may bind names when it matches
+type Pattern interface {
+    Node
+    _patternNode()
+}
+
+var (
+    _ Pattern = (*WildcardPattern)(nil)
+    _ Pattern = (*Identifier)(nil)
+    _ Pattern = (*LiteralPattern)(nil)
+    _ Pattern = (*ArrayPattern)(nil)
+    _ Pattern = (*VariantPattern)(nil)
+)
+
+// Bindings lists the identifiers bound by pat, in order
+func Bindings(pat Pattern) []*Identifier {
+    switch pat := pat.(type) {
+    case *Identifier:
+        return []*Identifier{pat}
+    case *ArrayPattern:
+        idents := []*Identifier{}
+        for _, el := range pat.Elements {
+            idents = append(idents, Bindings(el)...)
+        }
+        if pat.Rest != nil { idents = append(idents, Bindings(pat.Rest)...) }
+
+        return idents
+    case *VariantPattern:
+        if ident, ok := pat.Constructor.(*Identifier); ok && pat.Binding { return []*Identifier{ident} }
+
+        idents := []*Identifier{}
+        for _, arg := range pat.Args {
+            idents = append(idents, Bindings(arg)...)
+        }
+
+        return idents
+    default:
+        return nil
+    }
+}
+
+// an identifier pattern matches anything and binds the value to its name
+func (i *Identifier) _patternNode(){}
+
+type WildcardPattern struct {
+    Token token.Token
+}
+
+func (wp *WildcardPattern) _patternNode(){}
+func (wp *WildcardPattern) Pos() token.Token { return wp.Token }
+func (wp *WildcardPattern) String() string { return "_" }
+
+type LiteralPattern struct {
+    Value Expression
+}
+
+func (lp *LiteralPattern) _patternNode(){}
+func (lp *LiteralPattern) Pos() token.Token { return lp.Value.Pos() }
+func (lp *LiteralPattern) String() string { return lp.Value.String() }
+
+// ArrayPattern matches arrays element-wise, Rest (if present) matches the remaining elements
+type ArrayPattern struct {
+    Token    token.Token
+    Elements []Pattern
+    Rest     Pattern
+}
+
+func (ap *ArrayPattern) _patternNode(){}
+func (ap *ArrayPattern) Pos() token.Token { return ap.Token }
+func (ap *ArrayPattern) String() string {
+    var out strings.Builder
+
+    elems := []string{}
+    for _, el := range ap.Elements {
+        elems = append(elems, el.String())
+    }
+    if ap.Rest != nil { elems = append(elems, ".." + ap.Rest.String()) }
+
+    out.WriteString("[")
+    out.WriteString(strings.Join(elems, ", "))
+    out.WriteString("]")
+
+    return out.String()
+}
+
+// VariantPattern matches values built by Constructor, Args (nil without parentheses)
+// are matched against the payload fields in order, a constructor that is not
+// callable (e.g. a field-less variant) is compared by equality
+type VariantPattern struct {
+    Token       token.Token
+    Constructor Expression
+    Args        []Pattern
+    Binding     bool // set by the resolver on a bare name which refers to nothing
+}
+
+func (vp *VariantPattern) _patternNode(){}
+func (vp *VariantPattern) Pos() token.Token { return vp.Token }
+func (vp *VariantPattern) String() string {
+    if vp.Args == nil { return vp.Constructor.String() }
+
+    args := []string{}
+    for _, arg := range vp.Args {
+        args = append(args, arg.String())
+    }
+
+    return vp.Constructor.String() + "(" + strings.Join(args, ", ") + ")"
+}
@@ -0,0 +1,63 @@
+package ast
+
+import (
+    "strings"
+
+    "lemur/token"
+)
+
+// TypeExpr is an optional type annotation ('let x: int', 'fn(a: [string]) -> bool'),
+// annotations are verified by the check package and ignored by the evaluator
+type TypeExpr interface {
+    Node
+    _typeNode()
+}
+
+var (
+    _ TypeExpr = (*NamedType)(nil)
+    _ TypeExpr = (*ArrayType)(nil)
+    _ TypeExpr = (*FunctionType)(nil)
+)
+
+// NamedType is a builtin type (int, float, string, bool, null, any) or a declared
+// struct or enum
+type NamedType struct {
+    Token token.Token
+    Name  string
+}
+
+func (nt *NamedType) _typeNode(){}
+func (nt *NamedType) Pos() token.Token { return nt.Token }
+func (nt *NamedType) String() string { return nt.Name }
+
+// ArrayType is an array whose elements all have type Element ('[int]')
+type ArrayType struct {
+    Token   token.Token
+    Element TypeExpr
+}
+
+func (at *ArrayType) _typeNode(){}
+func (at *ArrayType) Pos() token.Token { return at.Token }
+func (at *ArrayType) String() string { return "[" + at.Element.String() + "]" }
+
+// FunctionType is the type of a function value ('fn(int, string) -> bool'), Return
+// is nil when omitted
+type FunctionType struct {
+    Token      token.Token
+    Parameters []TypeExpr
+    Return     TypeExpr
+}
+
+func (ft *FunctionType) _typeNode(){}
+func (ft *FunctionType) Pos() token.Token { return ft.Token }
+func (ft *FunctionType) String() string {
+    params := []string{}
+    for _, p := range ft.Parameters {
+        params = append(params, p.String())
+    }
+
+    out := "fn(" + strings.Join(params, ", ") + ")"
+    if ft.Return != nil { out += " -> " + ft.Return.String() }
+
+    return out
+}
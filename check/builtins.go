@@ -0,0 +1,85 @@
+package check
+
+import (
+    "lemur/eval"
+    "lemur/token"
+)
+
+// builtins holds the signatures of the evaluator's builtins, arguments beyond the
+// listed parameters are not checked (optional and variadic arguments are left to
+// the evaluator)
+var builtins = map[string]*Function{
+    eval.Len:     fn(Int, Any),
+    eval.First:   fn(Any, Any),
+    eval.Last:    fn(Any, Any),
+    eval.Head:    fn(Any, Any),
+    eval.Tail:    fn(Any, Any),
+    eval.Push:    fn(Any, Any, Any),
+    eval.Map:     fn(Any, Any, Any),
+    eval.Filter:  fn(Any, Any, Any),
+    eval.Reduce:  fn(Any, Any, Any, Any),
+    eval.Any:     fn(Bool, Any, Any),
+    eval.All:     fn(Bool, Any, Any),
+    eval.Find:    fn(Any, Any, Any),
+    eval.Sort:    fn(Any, Any),
+    eval.SortBy:  fn(Any, Any, Any),
+    eval.Range:   fn(Any, Int, Int, Int),
+    eval.Collect: fn(Any, Any),
+
+    eval.Split:      fn(&Array{Elem: String}, String, String),
+    eval.Join:       fn(String, Any, String),
+    eval.Trim:       fn(String, String),
+    eval.TrimLeft:   fn(String, String),
+    eval.TrimRight:  fn(String, String),
+    eval.Upper:      fn(String, String),
+    eval.Lower:      fn(String, String),
+    eval.Replace:    fn(String, String, String, String),
+    eval.Contains:   fn(Bool, Any, Any),
+    eval.StartsWith: fn(Bool, String, String),
+    eval.EndsWith:   fn(Bool, String, String),
+    eval.IndexOf:    fn(Int, Any, Any),
+    eval.Repeat:     fn(String, String, Int),
+    eval.PadLeft:    fn(String, String, Int),
+    eval.PadRight:   fn(String, String, Int),
+    eval.Chars:      fn(&Array{Elem: String}, String),
+
+    eval.Type:      fn(String, Any),
+    eval.Str:       fn(String, Any),
+    eval.Int:       fn(Int, Any),
+    eval.Float:     fn(Float, Any),
+    eval.Bool:      fn(Bool, Any),
+    eval.IsString:  fn(Bool, Any),
+    eval.IsInt:     fn(Bool, Any),
+    eval.IsFloat:   fn(Bool, Any),
+    eval.IsBool:    fn(Bool, Any),
+    eval.IsArray:   fn(Bool, Any),
+    eval.IsFn:      fn(Bool, Any),
+    eval.IsNull:    fn(Bool, Any),
+    eval.IsVariant: fn(Bool, Any, Any),
+    eval.IsFrozen:  fn(Bool, Any),
+}
+
+func fn(ret Type, params ...Type) *Function { return &Function{Params: params, Return: ret} }
+
+// genericResult refines the result of builtins whose type depends on their
+// arguments (it returns nil if there is nothing to refine), push also enforces
+// homogeneous arrays like its runtime counterpart
+func (c *checker) genericResult(tok token.Token, name string, args []Type) Type {
+    if len(args) == 0 { return nil }
+    arr, ok := args[0].(*Array)
+    if !ok { return nil }
+
+    switch name {
+    case eval.First, eval.Last:
+        return arr.Elem
+    case eval.Head, eval.Tail, eval.Sort, eval.SortBy:
+        return arr
+    case eval.Push:
+        if len(args) == 2 && !assignable(args[1], arr.Elem) {
+            c.errorf(tok, TypeMismatchError, "%s(%s, %s)", eval.Push, arr, args[1])
+        }
+        return arr
+    default:
+        return nil
+    }
+}
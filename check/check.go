@@ -0,0 +1,592 @@
+// Package check performs local type inference over a parsed program and reports
+// type errors before it is run, unannotated values whose type cannot be inferred
+// are treated as Any and never reported
+package check
+
+import (
+    "fmt"
+
+    "lemur/ast"
+    "lemur/eval"
+    "lemur/token"
+)
+
+const (
+    TypeMismatchError     = "type mismatch"
+    UnknownOperatorError  = "unknown operator"
+    InvalidConditionError = "invalid condition"
+    InvalidIndexError     = "invalid index"
+    UnknownTypeError      = "unknown type"
+    ArgumentTypeError     = "wrong argument type"
+    ReturnTypeError       = "wrong return type"
+)
+
+// Error is a type error at the given position of the source
+type Error struct {
+    Line    int
+    Col     int
+    Message string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Message) }
+
+type scope struct {
+    vars  map[string]Type
+    outer *scope
+}
+
+func (s *scope) lookup(name string) (Type, bool) {
+    for sc := s; sc != nil; sc = sc.outer {
+        if t, ok := sc.vars[name]; ok { return t, true }
+    }
+    return nil, false
+}
+
+// frame is the function currently being checked
+type frame struct {
+    name     string
+    declared Type   // annotated return type or nil
+    returns  []Type // types of the explicit returns
+}
+
+type checker struct {
+    errors []*Error
+    scope  *scope
+    types  map[string]Type
+    frames []*frame
+    values map[ast.Expression]Type // the type inferred for each expression
+}
+
+// Check reports the type errors in program in source order
+func Check(program ast.Program) []*Error {
+    c := &checker{
+        scope: &scope{vars: map[string]Type{}},
+        values: map[ast.Expression]Type{},
+        types: map[string]Type{
+            eval.OptionEnum: &Named{Name: eval.OptionEnum},
+            eval.ResultEnum: &Named{Name: eval.ResultEnum},
+        },
+    }
+
+    // types can be used in annotations before their declaration
+    for _, stmt := range program {
+        if export, ok := stmt.(*ast.ExportStatement); ok { stmt = export.Statement }
+        c.declareType(stmt)
+    }
+
+    c.block(program)
+    return c.errors
+}
+
+func (c *checker) errorf(tok token.Token, kind, format string, args ...any) {
+    msg := kind + ": " + fmt.Sprintf(format, args...)
+    c.errors = append(c.errors, &Error{Line: tok.Line, Col: tok.Col, Message: msg})
+}
+
+func (c *checker) errorAt(node ast.Node, kind, format string, args ...any) {
+    c.errorf(node.Pos(), kind, format, args...)
+}
+
+func (c *checker) push() { c.scope = &scope{vars: map[string]Type{}, outer: c.scope} }
+func (c *checker) pop()  { c.scope = c.scope.outer }
+
+func (c *checker) define(name string, t Type) { c.scope.vars[name] = t }
+
+func (c *checker) declareType(stmt ast.Statement) {
+    switch stmt := stmt.(type) {
+    case *ast.StructStatement:
+        c.types[stmt.Name.Value] = &Named{Name: stmt.Name.Value}
+    case *ast.EnumStatement:
+        c.types[stmt.Name.Value] = &Named{Name: stmt.Name.Value}
+    }
+}
+
+// block checks stmts in the current scope and returns the type of the value the
+// block evaluates to
+func (c *checker) block(stmts []ast.Statement) Type {
+    var t Type = Null
+    for _, stmt := range stmts {
+        t = c.statement(stmt)
+    }
+
+    return t
+}
+
+func (c *checker) scopedBlock(bs *ast.BlockStatement) Type {
+    c.push()
+    defer c.pop()
+
+    return c.block(bs.Statements)
+}
+
+func (c *checker) statement(stmt ast.Statement) Type {
+    switch stmt := stmt.(type) {
+    case *ast.ExpressionStatement:
+        return c.expression(stmt.Value)
+    case *ast.LetStatement:
+        return c.letStatement(stmt)
+    case *ast.ReturnStatement:
+        t := c.expression(stmt.Value)
+        c.checkReturn(stmt.Value, t)
+
+        if n := len(c.frames); n > 0 { c.frames[n - 1].returns = append(c.frames[n - 1].returns, t) }
+        return t
+    case *ast.BlockStatement:
+        return c.scopedBlock(stmt)
+    case *ast.ThrowStatement:
+        c.expression(stmt.Value)
+        return Any
+    case *ast.StructStatement:
+        c.declareType(stmt)
+
+        params := make([]Type, len(stmt.Fields))
+        for i := range params { params[i] = Any }
+        c.define(stmt.Name.Value, &Function{Params: params, Return: c.types[stmt.Name.Value]})
+        return Any
+    case *ast.EnumStatement:
+        c.declareType(stmt)
+        c.define(stmt.Name.Value, Any)
+        return Any
+    case *ast.ImportStatement:
+        c.define(stmt.Name(), Any)
+        return Any
+    case *ast.ExportStatement:
+        return c.statement(stmt.Statement)
+    default:
+        return Any
+    }
+}
+
+func (c *checker) letStatement(ls *ast.LetStatement) Type {
+    var declared Type = Any
+    if ls.Type != nil { declared = c.resolveType(ls.Type) }
+
+    var t Type
+    if fl, ok := ls.Value.(*ast.FunctionLiteral); ok && ls.Name != nil {
+        // let bound functions may call themselves
+        sig := c.signature(fl)
+        c.define(ls.Name.Value, sig)
+        t = c.function(fl, sig, ls.Name.Value)
+    } else {
+        t = c.expression(ls.Value)
+    }
+
+    if !assignable(t, declared) {
+        c.errorAt(ls.Value, TypeMismatchError, "%s %s: %s = %s", ls.Token.Literal, bindingName(ls), declared, t)
+    } else if m := c.mismatch(ls.Value, declared); m != nil {
+        c.errorAt(m.node, TypeMismatchError, "%s %s%s: %s = %s", ls.Token.Literal, bindingName(ls), m.path, m.want, m.got)
+    }
+    if ls.Type != nil { t = declared }
+
+    if ls.Name != nil {
+        c.define(ls.Name.Value, t)
+    } else {
+        c.bindPattern(ls.Pattern, t)
+    }
+    return t
+}
+
+func bindingName(ls *ast.LetStatement) string {
+    if ls.Name != nil { return ls.Name.Value }
+    return ls.Pattern.String()
+}
+
+// bindPattern defines the names bound by matching pat against a value of type t
+func (c *checker) bindPattern(pat ast.Pattern, t Type) {
+    if vp, ok := pat.(*ast.VariantPattern); ok && vp.Binding {
+        c.define(vp.Constructor.String(), t)
+        return
+    }
+
+    switch pat := pat.(type) {
+    case *ast.Identifier:
+        c.define(pat.Value, t)
+    case *ast.ArrayPattern:
+        var elem Type = Any
+        if arr, ok := t.(*Array); ok { elem = arr.Elem }
+
+        for _, el := range pat.Elements {
+            c.bindPattern(el, elem)
+        }
+        if pat.Rest != nil { c.bindPattern(pat.Rest, &Array{Elem: elem}) }
+    default:
+        for _, ident := range ast.Bindings(pat) {
+            c.define(ident.Value, Any)
+        }
+    }
+}
+
+func (c *checker) checkReturn(node ast.Node, t Type) {
+    if len(c.frames) == 0 { return }
+
+    f := c.frames[len(c.frames) - 1]
+    if f.declared == nil { return }
+
+    if !assignable(t, f.declared) {
+        c.errorAt(node, ReturnTypeError, "%s returns %s, got %s", f.name, f.declared, t)
+        return
+    }
+
+    if es, ok := node.(*ast.ExpressionStatement); ok { node = es.Value }
+    if exp, ok := node.(ast.Expression); ok {
+        if m := c.mismatch(exp, f.declared); m != nil && m.path == "" {
+            c.errorAt(m.node, ReturnTypeError, "%s returns %s, got %s", f.name, m.want, m.got)
+        } else if m != nil {
+            c.errorAt(m.node, ReturnTypeError, "%s returns %s, got %s at %s", f.name, f.declared, m.got, m.path)
+        }
+    }
+}
+
+// signature is the type of a function literal according to its annotations
+func (c *checker) signature(fl *ast.FunctionLiteral) *Function {
+    f := &Function{Params: []Type{}, Return: Any}
+    for _, p := range fl.Parameters {
+        if p.Rest { break } // any number of arguments
+
+        var t Type = Any
+        if p.Type != nil { t = c.resolveType(p.Type) }
+        f.Params = append(f.Params, t)
+    }
+    if fl.ReturnType != nil { f.Return = c.resolveType(fl.ReturnType) }
+
+    return f
+}
+
+// function checks the body of fl against its signature sig, an unannotated return
+// type is inferred from the body
+func (c *checker) function(fl *ast.FunctionLiteral, sig *Function, name string) Type {
+    c.push()
+    defer c.pop()
+
+    for i, p := range fl.Parameters {
+        var t Type = &Array{Elem: Any}
+        if i < len(sig.Params) { t = sig.Params[i] }
+        if p.Rest && p.Type != nil { t = c.resolveType(p.Type) }
+
+        if p.Default != nil {
+            if dt := c.expression(p.Default); !assignable(dt, t) {
+                c.errorAt(p.Default, TypeMismatchError, "%s: %s = %s", p.Pattern, t, dt)
+            } else if m := c.mismatch(p.Default, t); m != nil {
+                c.errorAt(m.node, TypeMismatchError, "%s%s: %s = %s", p.Pattern, m.path, m.want, m.got)
+            }
+        }
+        c.bindPattern(p.Pattern, t)
+    }
+
+    f := &frame{name: name}
+    if fl.ReturnType != nil { f.declared = sig.Return }
+    c.frames = append(c.frames, f)
+    defer func() { c.frames = c.frames[:len(c.frames) - 1] }()
+
+    t := c.block(fl.Body.Statements)
+
+    // the last statement is returned implicitly (explicit returns are checked as they occur)
+    stmts := fl.Body.Statements
+    if n := len(stmts); n > 0 {
+        if _, ok := stmts[n - 1].(*ast.ReturnStatement); !ok { c.checkReturn(stmts[n - 1], t) }
+    }
+
+    if fl.ReturnType == nil { sig.Return = same(append(f.returns, t)...) }
+    return sig
+}
+
+func (c *checker) expression(exp ast.Expression) Type {
+    t := c.infer(exp)
+    c.values[exp] = t
+    return t
+}
+
+// mismatch is the part of a value which cannot be assigned to the type it is checked
+// against, path locates it from the value (e.g. "[1]" for an element)
+type mismatch struct {
+    node ast.Expression
+    path string
+    got  Type
+    want Type
+}
+
+// mismatch looks into the elements of array literals and the branches of conditionals,
+// whose own types fall back to any when they differ, for a part of exp which cannot
+// be assigned to want
+func (c *checker) mismatch(exp ast.Expression, want Type) *mismatch {
+    switch exp := exp.(type) {
+    case *ast.ArrayLiteral:
+        arr, ok := want.(*Array)
+        if !ok { break }
+
+        for i, el := range exp.Elements {
+            if _, ok := el.(*ast.SpreadExpression); ok { continue }
+            if m := c.mismatch(el, arr.Elem); m != nil {
+                m.path = fmt.Sprintf("[%d]%s", i, m.path)
+                return m
+            }
+        }
+        return nil
+    case *ast.ConditionalExpression:
+        if exp.Alternative == nil { break }
+
+        cons, consOk := lastValue(exp.Consequence)
+        alt, altOk := lastValue(exp.Alternative)
+        if !consOk || !altOk { break }
+
+        if m := c.mismatch(cons, want); m != nil { return m }
+        return c.mismatch(alt, want)
+    }
+
+    if t, ok := c.values[exp]; ok && !assignable(t, want) { return &mismatch{node: exp, got: t, want: want} }
+    return nil
+}
+
+// lastValue is the expression a block evaluates to, if it ends with one
+func lastValue(bs *ast.BlockStatement) (ast.Expression, bool) {
+    if len(bs.Statements) == 0 { return nil, false }
+
+    es, ok := bs.Statements[len(bs.Statements) - 1].(*ast.ExpressionStatement)
+    if !ok { return nil, false }
+    return es.Value, true
+}
+
+func (c *checker) infer(exp ast.Expression) Type {
+    switch exp := exp.(type) {
+    case *ast.IntegerLiteral:
+        return Int
+    case *ast.FloatLiteral:
+        return Float
+    case *ast.StringLiteral:
+        return String
+    case *ast.BooleanLiteral:
+        return Bool
+    case *ast.NullLiteral:
+        return Null
+    case *ast.Identifier:
+        if t, ok := c.scope.lookup(exp.Value); ok { return t }
+        if b, ok := builtins[exp.Value]; ok { return b }
+        return Any
+    case *ast.ArrayLiteral:
+        elems := []Type{}
+        for _, el := range exp.Elements {
+            elems = append(elems, c.expression(el))
+        }
+        return &Array{Elem: same(elems...)}
+    case *ast.PrefixExpression:
+        return c.prefix(exp)
+    case *ast.InfixExpression:
+        return c.infix(exp)
+    case *ast.ConditionalExpression:
+        c.condition(exp.Condition)
+
+        cons := c.scopedBlock(exp.Consequence)
+        if exp.Alternative == nil { return Any }
+
+        return same(cons, c.scopedBlock(exp.Alternative))
+    case *ast.IndexExpression:
+        return c.index(exp)
+    case *ast.SliceExpression:
+        t := c.expression(exp.Left)
+        for _, e := range []ast.Expression{exp.Start, exp.End, exp.Step} {
+            if e != nil { c.expression(e) }
+        }
+
+        if _, ok := t.(*Array); ok || t == String { return t }
+        return Any
+    case *ast.MemberExpression:
+        c.expression(exp.Left)
+        return Any
+    case *ast.FunctionLiteral:
+        return c.function(exp, c.signature(exp), "fn")
+    case *ast.CallExpression:
+        return c.call(exp)
+    case *ast.MatchExpression:
+        return c.match(exp)
+    case *ast.TryExpression:
+        body := c.scopedBlock(exp.Body)
+        if exp.Catch != nil {
+            c.push()
+            if exp.CatchName != nil { c.define(exp.CatchName.Value, Any) }
+            body = same(body, c.block(exp.Catch.Statements))
+            c.pop()
+        }
+        if exp.Finally != nil { c.scopedBlock(exp.Finally) }
+
+        return body
+    case *ast.WithExpression:
+        t := c.expression(exp.Left)
+        for _, u := range exp.Updates {
+            c.expression(u.Value)
+        }
+        return t
+    case *ast.SpreadExpression:
+        return c.expression(exp.Value)
+    case *ast.NamedArgument:
+        return c.expression(exp.Value)
+    default:
+        return Any
+    }
+}
+
+func (c *checker) condition(exp ast.Expression) {
+    if t := c.expression(exp); t != Bool && t != Any {
+        c.errorAt(exp, InvalidConditionError, "%s", t)
+    }
+}
+
+func (c *checker) prefix(pe *ast.PrefixExpression) Type {
+    t := c.expression(pe.Right)
+    if t == Any { return Any }
+
+    switch {
+    case pe.Operator == "-" && isNumeric(t):
+        return t
+    case pe.Operator == "!" && t == Bool:
+        return Bool
+    default:
+        c.errorf(pe.Token, UnknownOperatorError, "%s%s", pe.Operator, t)
+        return Any
+    }
+}
+
+// infix mirrors the evaluator's rules for combining values
+func (c *checker) infix(ie *ast.InfixExpression) Type {
+    left, right := c.expression(ie.Left), c.expression(ie.Right)
+    op := ie.Operator
+
+    unknown := func() Type {
+        c.errorf(ie.Token, UnknownOperatorError, "%s %s %s", left, op, right)
+        return Any
+    }
+
+    switch {
+    case op == "??":
+        if left == Null { return right }
+        return same(left, right)
+    case op == ".." || op == "..=":
+        if (left != Int && left != Any) || (right != Int && right != Any) { return unknown() }
+        return Any
+    case left == Any || right == Any:
+        switch op {
+        case "<", ">", "==", "!=", "&&", "||":
+            return Bool
+        default:
+            return Any
+        }
+    case left == Null || right == Null:
+        if op != "==" && op != "!=" { return unknown() }
+        return Bool
+    case isNumeric(left) && isNumeric(right):
+        switch op {
+        case "+", "-", "*", "/":
+            if left == Float || right == Float { return Float }
+            return Int
+        case "<", ">", "==", "!=":
+            return Bool
+        default:
+            return unknown()
+        }
+    case kind(left) != kind(right):
+        c.errorf(ie.Token, TypeMismatchError, "%s %s %s", left, op, right)
+        return Any
+    }
+
+    switch {
+    case op == "==" || op == "!=":
+        return Bool
+    case left == String && op == "+":
+        return String
+    case (left == String || kind(left) == "array") && (op == "<" || op == ">"):
+        return Bool
+    case left == Bool && (op == "&&" || op == "||"):
+        return Bool
+    default:
+        return unknown()
+    }
+}
+
+func (c *checker) index(ie *ast.IndexExpression) Type {
+    left, index := c.expression(ie.Left), c.expression(ie.Index)
+
+    arr, isArray := left.(*Array)
+    if !isArray && left != String { return Any }
+
+    if index != Int && index != Any {
+        c.errorAt(ie.Index, InvalidIndexError, "%s[%s]", left, index)
+        return Any
+    }
+    if ie.Optional { return Any } // may be null
+
+    if isArray { return arr.Elem }
+    return String
+}
+
+func (c *checker) call(ce *ast.CallExpression) Type {
+    args := []ast.Expression{}
+    name := ce.Function.String()
+
+    types := []Type{}
+
+    var callee Type = Any
+    if me, ok := ce.Function.(*ast.MemberExpression); ok {
+        // method call syntax passes the receiver as the first argument, members of
+        // namespaces, modules and records are called directly (unknown statically)
+        recv := c.expression(me.Left)
+        if _, ok := recv.(*Named); !ok && recv != Any {
+            if t := c.method(me.Member.Value); t != nil { callee, name = t, me.Member.Value }
+        }
+
+        args, types = append(args, me.Left), append(types, recv)
+    } else {
+        callee = c.expression(ce.Function)
+    }
+    args = append(args, ce.Arguments...)
+
+    checked := true // positional arguments line up with parameters
+    for _, arg := range ce.Arguments {
+        switch arg.(type) {
+        case *ast.SpreadExpression, *ast.NamedArgument:
+            checked = false
+        }
+        types = append(types, c.expression(arg))
+    }
+
+    f, ok := callee.(*Function)
+    if !ok { return Any }
+
+    if checked {
+        for i := range min(len(types), len(f.Params)) {
+            if !assignable(types[i], f.Params[i]) {
+                c.errorAt(args[i], ArgumentTypeError, "%s argument %d expects %s, got %s", name, i + 1, f.Params[i], types[i])
+            } else if m := c.mismatch(args[i], f.Params[i]); m != nil {
+                c.errorAt(m.node, ArgumentTypeError, "%s argument %d%s expects %s, got %s", name, i + 1, m.path, m.want, m.got)
+            }
+        }
+    }
+
+    if b, ok := builtins[name]; ok && b == callee {
+        if t := c.genericResult(ce.Token, name, types); t != nil { return t }
+    }
+    return f.Return
+}
+
+// method resolves a method call the way the evaluator does, builtins first and then
+// functions in scope
+func (c *checker) method(name string) Type {
+    if b, ok := builtins[name]; ok { return b }
+    if t, ok := c.scope.lookup(name); ok { return t }
+
+    return nil
+}
+
+func (c *checker) match(me *ast.MatchExpression) Type {
+    subject := c.expression(me.Subject)
+
+    arms := []Type{}
+    for _, arm := range me.Arms {
+        c.push()
+        c.bindPattern(arm.Pattern, subject)
+        if arm.Guard != nil { c.condition(arm.Guard) }
+
+        arms = append(arms, c.statement(arm.Body))
+        c.pop()
+    }
+
+    return same(arms...)
+}
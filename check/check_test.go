@@ -0,0 +1,127 @@
+package check
+
+import (
+    "testing"
+
+    "lemur/lexer"
+    "lemur/parser"
+)
+
+func TestTypeErrors(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        {`let x: int = "five"`, "1:14: " + TypeMismatchError + ": let x: int = string"},
+        {`const xs: [int] = [1, "a"]`, "1:23: " + TypeMismatchError + ": const xs[1]: int = string"},
+        {`let xs: [[int]] = [[1], [2, 3.5]]`, "1:29: " + TypeMismatchError + ": let xs[1][1]: int = float"},
+        {`let xs: [any] = [1, "a"]`, ""},
+        {`let xs: [float] = [1, 2.5]`, "1:20: " + TypeMismatchError + ": let xs[0]: float = int"},
+        {`let x: int = if (true) { 1 } else { "a" }`, "1:37: " + TypeMismatchError + ": let x: int = string"},
+        {`let xs: [int] = if (true) { [1] } else { ["a"] }`, "1:43: " + TypeMismatchError + ": let xs[0]: int = string"},
+        {`let f = fn(a: [int]) { a }; f([1, "a"])`, "1:35: " + ArgumentTypeError + ": f argument 1[1] expects int, got string"},
+        {`let f = fn(a: [int] = [1, "a"]) { a }`, "1:27: " + TypeMismatchError + ": a[1]: int = string"},
+        {`let f = fn(n) -> int { if (n) { 1 } else { "a" } }`, "1:44: " + ReturnTypeError + ": f returns int, got string"},
+        {`let f = fn(n) -> [int] { [n, "a"] }`, "1:30: " + ReturnTypeError + ": f returns [int], got string at [1]"},
+        {`const xs: [int] = [1.5]`, "1:19: " + TypeMismatchError + ": const xs: [int] = [float]"},
+        {`let x: float = 1`, "1:16: " + TypeMismatchError + ": let x: float = int"},
+        {`let [a, b]: [int] = [1, 2]; a + "s"`, "1:31: " + TypeMismatchError + ": int + string"},
+        {`1 + true`, "1:3: " + TypeMismatchError + ": int + bool"},
+        {`"a" * 2`, "1:5: " + TypeMismatchError + ": string * int"},
+        {`"a" - "b"`, "1:5: " + UnknownOperatorError + ": string - string"},
+        {`[1] + [2]`, "1:5: " + UnknownOperatorError + ": [int] + [int]"},
+        {`null + 1`, "1:6: " + UnknownOperatorError + ": null + int"},
+        {`1 .. "a"`, "1:3: " + UnknownOperatorError + ": int .. string"},
+        {`-"a"`, "1:1: " + UnknownOperatorError + ": -string"},
+        {`!1`, "1:1: " + UnknownOperatorError + ": !int"},
+        {`if (1) { 2 }`, "1:5: " + InvalidConditionError + ": int"},
+        {`match 1 { x if x => x }`, "1:16: " + InvalidConditionError + ": int"},
+        {`[1, 2]["a"]`, "1:8: " + InvalidIndexError + ": [int][string]"},
+        {`let f = fn(a: int) { a }; f("a")`, "1:29: " + ArgumentTypeError + ": f argument 1 expects int, got string"},
+        {`let f = fn(a: int, b: [string]) { a }; f(1, [2])`, "1:45: " + ArgumentTypeError + ": f argument 2 expects [string], got [int]"},
+        {`let f = fn(s: string) { s }; 1.f()`, "1:30: " + ArgumentTypeError + ": f argument 1 expects string, got int"},
+        {`upper(1)`, "1:7: " + ArgumentTypeError + ": upper argument 1 expects string, got int"},
+        {`"a".repeat("b")`, "1:12: " + ArgumentTypeError + ": repeat argument 2 expects int, got string"},
+        {`push([1], "a")`, "1:5: " + TypeMismatchError + ": push([int], string)"},
+        {`let xs: [string] = []; xs.push(1)`, "1:31: " + TypeMismatchError + ": push([string], int)"},
+        {`let f = fn(n: int) -> bool { n + 1 }`, "1:30: " + ReturnTypeError + ": f returns bool, got int"},
+        {`let f = fn(n) -> int { if (n) { return "a" } 1 }`, "1:40: " + ReturnTypeError + ": f returns int, got string"},
+        {`fn(n) -> int { let x = "s" }`, "1:16: " + ReturnTypeError + ": fn returns int, got string"},
+        {`let f = fn(n: int = "a") { n }`, "1:21: " + TypeMismatchError + ": n: int = string"},
+        {`let f = fn() -> int { 1 }; f() + "a"`, "1:32: " + TypeMismatchError + ": int + string"},
+        {`let f = fn() { 1.5 }; let x: int = f()`, "1:37: " + TypeMismatchError + ": let x: int = float"},
+        {`let f: fn(string) -> int = fn(n: int) -> int { n }`, "1:28: " + TypeMismatchError + ": let f: fn(string) -> int = fn(int) -> int"},
+        {`let p: Pont = 1`, "1:8: " + UnknownTypeError + ": Pont"},
+        {`let f = fn(xs: [Foo]) { xs }`, "1:17: " + UnknownTypeError + ": Foo"},
+        {`struct P { x }; let p: P = 1`, "1:28: " + TypeMismatchError + ": let p: P = int"},
+        {`let o: Option = Some(1); o + 1`, "1:28: " + TypeMismatchError + ": Option + int"},
+        {`let s: string = first(["a"]) + 1`, "1:30: " + TypeMismatchError + ": string + int"},
+        {`let xs = split("a,b", ","); xs[0] * 2`, "1:35: " + TypeMismatchError + ": string * int"},
+        {`let x = if (true) { 1 } else { 2 }; x + "a"`, "1:39: " + TypeMismatchError + ": int + string"},
+        {`{ let x: int = 1; } let x: string = 1`, "1:37: " + TypeMismatchError + ": let x: string = int"},
+        {`export let x: bool = 1`, "1:22: " + TypeMismatchError + ": let x: bool = int"},
+    }
+
+    for i, tst := range tests {
+        errs := runCheck(t, tst.input)
+
+        if tst.expected == "" {
+            if len(errs) != 0 { t.Errorf("test %d: unexpected error %s", i + 1, errs[0]) }
+            continue
+        }
+        if len(errs) != 1 {
+            t.Errorf("test %d: expected 1 error, got %d %v", i + 1, len(errs), errs)
+            continue
+        }
+        assert(t, i, errs[0].Error(), tst.expected)
+    }
+}
+
+// unannotated code whose types cannot be inferred locally must never be reported
+func TestWellTyped(t *testing.T) {
+    tests := []string{
+        `let f = fn(x) { x }; f(1) + f("a")`,
+        `let x = if (true) { 1 } else { "s" }; x + 1`,
+        `let add = fn(a: int, b: int = 2, ...rest: [int]) -> int { a + b + len(rest) }; add(1, b: 3) + add(...[1, 2])`,
+        `let fib = fn(n: int) -> int { if (n < 2) { return n } fib(n - 1) + fib(n - 2) }; fib(10) + 1`,
+        `null ?? 1`,
+        `1 == 1.5`,
+        `1 + 2.5 - 3 * 4 / 5`,
+        `"a" < "b" && [1] < [2] || true`,
+        `[1, "a"] == [1, "a"]`,
+        `null == 1`,
+        `let xs = []; push(xs, 1)`,
+        `let xs: [int] = [1]; xs.push(2).len() + first(xs) + last(tail(xs))`,
+        `math.sqrt(4) + 1.5`,
+        `struct P { x }; P(1).x + "a"`,
+        `struct P { x }; let p: P = P(1); p == P(2)`,
+        `struct Box { f }; Box(fn(n) { n }).f("a")`,
+        `match [1, 2] { [a, ..rest] => a + len(rest), _ => 0 }`,
+        `match Some(1) { Some(x) => x + "a", None => 0 }`,
+        `try { 1 } catch e { e.message }`,
+        `let f = fn(g: fn(int) -> int) { g(1) }; f(fn(n) { n }) + f(len)`,
+        `let s: string = str(1) + type(1)`,
+        `0..10 |> map(fn(i) { i * 2 }) |> collect`,
+        `let o: Option = None; let r: Result = Ok(1)`,
+        `import "util"; util.f(1) + 1`,
+    }
+
+    for i, input := range tests {
+        errs := runCheck(t, input)
+        if len(errs) != 0 { t.Errorf("test %d: unexpected error %s", i + 1, errs[0]) }
+    }
+}
+
+func runCheck(t *testing.T, input string) []*Error {
+    p := parser.New(lexer.New(input))
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0 { t.Fatalf("failed to parse %q: %s", input, p.Errors()[0]) }
+
+    return Check(program)
+}
+
+func assert(t *testing.T, testIdx int, val, expected any) {
+    if val != expected {
+        t.Errorf("test %d: incorrect value, expected %v (got %v)", testIdx + 1, expected, val)
+    }
+}
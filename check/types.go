@@ -0,0 +1,134 @@
+package check
+
+import (
+    "strings"
+
+    "lemur/ast"
+)
+
+// Type is the static type of an expression, Any is used wherever a type cannot be
+// inferred locally and is compatible with every other type
+type Type interface {
+    String() string
+}
+
+type Basic string
+
+const (
+    Any    Basic = "any"
+    Int    Basic = "int"
+    Float  Basic = "float"
+    String Basic = "string"
+    Bool   Basic = "bool"
+    Null   Basic = "null"
+)
+
+func (b Basic) String() string { return string(b) }
+
+// Named is a declared struct or enum, the type of its records and variants
+type Named struct {
+    Name string
+}
+
+func (n *Named) String() string { return n.Name }
+
+type Array struct {
+    Elem Type
+}
+
+func (a *Array) String() string { return "[" + a.Elem.String() + "]" }
+
+type Function struct {
+    Params []Type
+    Return Type
+}
+
+func (f *Function) String() string {
+    params := []string{}
+    for _, p := range f.Params {
+        params = append(params, p.String())
+    }
+
+    return "fn(" + strings.Join(params, ", ") + ") -> " + f.Return.String()
+}
+
+// assignable reports whether a value of type from can be used where to is expected,
+// there are no implicit conversions (an int is not a float)
+func assignable(from, to Type) bool {
+    if from == Any || to == Any { return true }
+
+    switch to := to.(type) {
+    case *Array:
+        from, ok := from.(*Array)
+        return ok && assignable(from.Elem, to.Elem)
+    case *Function:
+        from, ok := from.(*Function)
+        if !ok { return false }
+
+        // extra parameters may have defaults, missing ones are caught at runtime
+        for i := range min(len(from.Params), len(to.Params)) {
+            if !assignable(to.Params[i], from.Params[i]) { return false }
+        }
+        return assignable(from.Return, to.Return)
+    default:
+        return from.String() == to.String()
+    }
+}
+
+// same returns t if every type in ts is t, otherwise Any
+func same(ts ...Type) Type {
+    if len(ts) == 0 { return Any }
+
+    for _, t := range ts[1:] {
+        if t.String() != ts[0].String() { return Any }
+    }
+    return ts[0]
+}
+
+// kind groups types the way the evaluator compares values, only values of the
+// same kind can be combined with an infix operator
+func kind(t Type) string {
+    switch t := t.(type) {
+    case *Array:
+        return "array"
+    case *Function:
+        return "function"
+    case *Named:
+        return "record"
+    default:
+        return t.String()
+    }
+}
+
+func isNumeric(t Type) bool { return t == Int || t == Float }
+
+// resolveType converts an annotation into a Type, unknown type names are reported
+// and treated as Any
+func (c *checker) resolveType(te ast.TypeExpr) Type {
+    switch te := te.(type) {
+    case *ast.NamedType:
+        switch Basic(te.Name) {
+        case Any, Int, Float, String, Bool, Null:
+            return Basic(te.Name)
+        }
+
+        t, ok := c.types[te.Name]
+        if !ok {
+            c.errorf(te.Token, UnknownTypeError, "%s", te.Name)
+            return Any
+        }
+        return t
+    case *ast.ArrayType:
+        return &Array{Elem: c.resolveType(te.Element)}
+    case *ast.FunctionType:
+        f := &Function{Params: []Type{}, Return: Any}
+        for _, p := range te.Parameters {
+            f.Params = append(f.Params, c.resolveType(p))
+        }
+        if te.Return != nil { f.Return = c.resolveType(te.Return) }
+
+        return f
+    default:
+        return Any
+    }
+}
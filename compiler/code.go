@@ -0,0 +1,227 @@
+package compiler
+
+import (
+    "encoding/binary"
+    "fmt"
+    "strings"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+    OpConstant Opcode = iota
+    OpNull
+    OpTrue
+    OpFalse
+    OpPop
+    OpDup
+    OpSwap
+
+    // variables
+    OpGetName
+    OpGetLocal
+    OpDefine
+    OpDefineLocal
+    OpNameFunction
+    OpCheckConst
+    OpFreeze
+    OpMarkConst
+    OpPushScope
+    OpPopScope
+
+    // control flow
+    OpJump
+    OpJumpIfFalse
+    OpJumpIfNull
+    OpJumpIfNotNull
+    OpBranch
+    OpGuard
+    OpReturn
+    OpEnd
+    OpTry
+    OpThrow
+    OpRaise
+
+    // operators
+    OpInfix
+    OpPrefix
+    OpEqual
+    OpArray
+    OpIndex
+    OpSliceBound
+    OpSlice
+    OpMember
+    OpWithCheck
+    OpWithField
+    OpWith
+
+    // calls
+    OpCall
+    OpCallArgs
+    OpCallMethod
+    OpSpread
+    OpParam
+    OpRest
+    OpArityError
+    OpClosure
+
+    // declarations
+    OpStruct
+    OpEnum
+    OpImport
+
+    // patterns
+    OpMatchArray
+    OpMatchVariant
+    OpMismatch
+    OpNoMatch
+)
+
+// Definition names an opcode and gives the width in bytes of each of its operands
+type Definition struct {
+    Name          string
+    OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+    OpConstant:      {"OpConstant", []int{2}},
+    OpNull:          {"OpNull", []int{}},
+    OpTrue:          {"OpTrue", []int{}},
+    OpFalse:         {"OpFalse", []int{}},
+    OpPop:           {"OpPop", []int{}},
+    OpDup:           {"OpDup", []int{}},
+    OpSwap:          {"OpSwap", []int{}},
+    OpGetName:       {"OpGetName", []int{2}},
+    OpGetLocal:      {"OpGetLocal", []int{2, 2, 2}},
+    OpDefine:        {"OpDefine", []int{2}},
+    OpDefineLocal:   {"OpDefineLocal", []int{2, 2}},
+    OpNameFunction:  {"OpNameFunction", []int{2}},
+    OpCheckConst:    {"OpCheckConst", []int{2}},
+    OpFreeze:        {"OpFreeze", []int{}},
+    OpMarkConst:     {"OpMarkConst", []int{2}},
+    OpPushScope:     {"OpPushScope", []int{}},
+    OpPopScope:      {"OpPopScope", []int{}},
+    OpJump:          {"OpJump", []int{2}},
+    OpJumpIfFalse:   {"OpJumpIfFalse", []int{2}},
+    OpJumpIfNull:    {"OpJumpIfNull", []int{2}},
+    OpJumpIfNotNull: {"OpJumpIfNotNull", []int{2}},
+    OpBranch:        {"OpBranch", []int{2, 2}},
+    OpGuard:         {"OpGuard", []int{2, 2}},
+    OpReturn:        {"OpReturn", []int{}},
+    OpEnd:           {"OpEnd", []int{}},
+    OpTry:           {"OpTry", []int{2, 2, 2}},
+    OpThrow:         {"OpThrow", []int{}},
+    OpRaise:         {"OpRaise", []int{2}},
+    OpInfix:         {"OpInfix", []int{1}},
+    OpPrefix:        {"OpPrefix", []int{1}},
+    OpEqual:         {"OpEqual", []int{}},
+    OpArray:         {"OpArray", []int{2}},
+    OpIndex:         {"OpIndex", []int{}},
+    OpSliceBound:    {"OpSliceBound", []int{1}},
+    OpSlice:         {"OpSlice", []int{1}},
+    OpMember:        {"OpMember", []int{2}},
+    OpWithCheck:     {"OpWithCheck", []int{}},
+    OpWithField:     {"OpWithField", []int{2, 2}},
+    OpWith:          {"OpWith", []int{2}},
+    OpCall:          {"OpCall", []int{1}},
+    OpCallArgs:      {"OpCallArgs", []int{1, 2}},
+    OpCallMethod:    {"OpCallMethod", []int{2, 1, 2}},
+    OpSpread:        {"OpSpread", []int{}},
+    OpParam:         {"OpParam", []int{1, 2}},
+    OpRest:          {"OpRest", []int{1}},
+    OpArityError:    {"OpArityError", []int{}},
+    OpClosure:       {"OpClosure", []int{2}},
+    OpStruct:        {"OpStruct", []int{2}},
+    OpEnum:          {"OpEnum", []int{2}},
+    OpImport:        {"OpImport", []int{2}},
+    OpMatchArray:    {"OpMatchArray", []int{2, 1}},
+    OpMatchVariant:  {"OpMatchVariant", []int{2, 2}},
+    OpMismatch:      {"OpMismatch", []int{2}},
+    OpNoMatch:       {"OpNoMatch", []int{}},
+}
+
+// NoOperand marks an absent optional operand (e.g. a try without a catch block)
+const NoOperand = 0xFFFF
+
+// Operators lists the infix and prefix operators by the index OpInfix and OpPrefix
+// refer to them with
+var Operators = []string{"+", "-", "*", "/", "<", ">", "==", "!=", "&&", "||", "..", "..=", "!"}
+
+func Lookup(op Opcode) (*Definition, error) {
+    def, ok := definitions[op]
+    if !ok { return nil, fmt.Errorf("opcode %d undefined", op) }
+
+    return def, nil
+}
+
+// Make encodes an instruction, operands are written big endian
+func Make(op Opcode, operands ...int) []byte {
+    def, ok := definitions[op]
+    if !ok { return []byte{} }
+
+    length := 1
+    for _, w := range def.OperandWidths { length += w }
+
+    ins := make([]byte, length)
+    ins[0] = byte(op)
+
+    offset := 1
+    for i, o := range operands {
+        switch def.OperandWidths[i] {
+        case 2:
+            binary.BigEndian.PutUint16(ins[offset:], uint16(o))
+        case 1:
+            ins[offset] = byte(o)
+        }
+        offset += def.OperandWidths[i]
+    }
+
+    return ins
+}
+
+// ReadOperands decodes the operands of def from ins, it also returns the number of
+// bytes read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+    operands := make([]int, len(def.OperandWidths))
+    offset := 0
+
+    for i, w := range def.OperandWidths {
+        switch w {
+        case 2:
+            operands[i] = int(ReadUint16(ins[offset:]))
+        case 1:
+            operands[i] = int(ins[offset])
+        }
+        offset += w
+    }
+
+    return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 { return binary.BigEndian.Uint16(ins) }
+
+func (ins Instructions) String() string {
+    var out strings.Builder
+
+    for i := 0; i < len(ins); {
+        def, err := Lookup(Opcode(ins[i]))
+        if err != nil {
+            fmt.Fprintf(&out, "ERROR: %s\n", err)
+            i++
+            continue
+        }
+
+        operands, read := ReadOperands(def, ins[i + 1:])
+        fmt.Fprintf(&out, "%04d %s", i, def.Name)
+        for _, o := range operands {
+            fmt.Fprintf(&out, " %d", o)
+        }
+        out.WriteString("\n")
+
+        i += 1 + read
+    }
+
+    return out.String()
+}
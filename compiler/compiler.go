@@ -0,0 +1,783 @@
+// Package compiler translates a resolved program into bytecode for the vm, the
+// evaluator remains the reference for the semantics of every instruction
+package compiler
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "slices"
+    "sort"
+    "strings"
+
+    "lemur/ast"
+    "lemur/eval"
+    "lemur/object"
+    "lemur/resolver"
+    "lemur/token"
+)
+
+const (
+    FunctionObj = "CompiledFunction"
+    SpreadArg   = "..."
+
+    ProgramTooLargeError = "program too large"
+)
+
+// ErrTooLarge is wrapped by the errors of programs which exceed the operands of an
+// instruction (e.g. more constants than a two byte index can address)
+var ErrTooLarge = errors.New(ProgramTooLargeError)
+
+// Function is a compiled function body, the main program or a block of a try
+// expression (blocks end with OpEnd rather than OpReturn)
+type Function struct {
+    Instructions Instructions
+    Positions    []Position
+    Params       []Param
+    Source       string // the function as printed by the evaluator
+}
+
+// Param describes a parameter to the vm, which binds arguments before running the
+// parameter's own instructions (only identifier parameters can be named)
+type Param struct {
+    Name    string
+    Default bool
+    Rest    bool
+}
+
+// Position is the source position of the instructions from Offset up to the next
+// position, a zero Line means errors are reported at the call site
+type Position struct {
+    Offset int
+    Line   int
+    Col    int
+}
+
+func (f *Function) Type() object.ObjectType { return FunctionObj }
+func (f *Function) String() string { return f.Source }
+
+// Position returns the source position of the instruction at offset ip
+func (f *Function) Position(ip int) (int, int) {
+    i := sort.Search(len(f.Positions), func(i int) bool { return f.Positions[i].Offset > ip }) - 1
+    if i < 0 { return 0, 0 }
+
+    return f.Positions[i].Line, f.Positions[i].Col
+}
+
+type Bytecode struct {
+    Main      *Function
+    Constants []object.Object
+}
+
+type Compiler struct {
+    constants []object.Object
+    names     map[string]int
+    builtins  map[string]int
+    units     []*unit
+    scopes    []bool // the scopes of the resolver from the outermost, true if elided
+    err       error
+}
+
+// unit is a function being compiled, scopes counts the scopes entered within it
+type unit struct {
+    fn     *Function
+    line   int
+    col    int
+    scopes int
+    main   bool
+}
+
+// failure is a jump taken when a pattern does not match, pops values are left on the
+// stack above the matched value
+type failure struct {
+    jump int
+    pops int
+}
+
+// Compile resolves program and compiles it, the main function ends with the value
+// of the last statement like eval.Eval
+func Compile(program ast.Program) (*Bytecode, error) {
+    resolver.New(eval.BuiltinNames(), eval.PreludeNames()).Resolve(program)
+
+    c := &Compiler{names: map[string]int{}, builtins: map[string]int{}}
+    c.enter(true)
+    c.statements(program)
+    c.emit(OpEnd)
+
+    main := c.leave()
+    if c.err != nil { return nil, c.err }
+
+    return &Bytecode{Main: main, Constants: c.constants}, nil
+}
+
+func (c *Compiler) unit() *unit { return c.units[len(c.units) - 1] }
+
+func (c *Compiler) enter(main bool) { c.units = append(c.units, &unit{fn: &Function{}, main: main}) }
+
+func (c *Compiler) leave() *Function {
+    u := c.unit()
+    c.units = c.units[:len(c.units) - 1]
+    return u.fn
+}
+
+func (c *Compiler) fail(node ast.Node) {
+    if c.err == nil { c.err = fmt.Errorf("cannot compile %T", node) }
+}
+
+func (c *Compiler) tooLarge(what string, max int) {
+    if c.err == nil { c.err = fmt.Errorf("%w: %s above %d", ErrTooLarge, what, max) }
+}
+
+// root reports whether declarations go to the environment the program is run in,
+// which may already hold constants (e.g. from earlier REPL inputs)
+func (c *Compiler) root() bool { return c.unit().main && c.unit().scopes == 0 }
+
+// at sets the source position of the instructions emitted next
+func (c *Compiler) at(tok token.Token) {
+    u := c.unit()
+    u.line, u.col = tok.Line, tok.Col
+}
+
+func (c *Compiler) emit(op Opcode, operands ...int) int {
+    def, _ := Lookup(op)
+    for i, o := range operands {
+        max := math.MaxUint16
+        if def.OperandWidths[i] == 1 { max = math.MaxUint8 }
+        if o < 0 || o > max { c.tooLarge(def.Name + " operand", max) }
+    }
+
+    u := c.unit()
+    pos := len(u.fn.Instructions)
+
+    n := len(u.fn.Positions)
+    if n == 0 || u.fn.Positions[n - 1].Line != u.line || u.fn.Positions[n - 1].Col != u.col {
+        u.fn.Positions = append(u.fn.Positions, Position{Offset: pos, Line: u.line, Col: u.col})
+    }
+
+    u.fn.Instructions = append(u.fn.Instructions, Make(op, operands...)...)
+    return pos
+}
+
+// patch points the jump at pos (its first two byte operand) to the next instruction
+func (c *Compiler) patch(pos int) {
+    ins := c.unit().fn.Instructions
+    def, _ := Lookup(Opcode(ins[pos]))
+
+    offset := pos + 1
+    for _, w := range def.OperandWidths {
+        if w == 2 { break }
+        offset += w
+    }
+
+    target := len(ins)
+    if target > math.MaxUint16 { c.tooLarge("jump target", math.MaxUint16) }
+    ins[offset], ins[offset + 1] = byte(target >> 8), byte(target)
+}
+
+// constant adds obj to the pool, the last index is kept for NoOperand
+func (c *Compiler) constant(obj object.Object) int {
+    if len(c.constants) == NoOperand { c.tooLarge("constant index", NoOperand - 1) }
+
+    c.constants = append(c.constants, obj)
+    return len(c.constants) - 1
+}
+
+func (c *Compiler) name(s string) int {
+    if i, ok := c.names[s]; ok { return i }
+
+    i := c.constant(&object.String{Value: s})
+    c.names[s] = i
+    return i
+}
+
+func (c *Compiler) nameList(names []string) int {
+    elems := make([]object.Object, 0, len(names))
+    for _, name := range names {
+        elems = append(elems, &object.String{Value: name})
+    }
+
+    return c.constant(&object.Array{Elements: elems})
+}
+
+// raise emits an error known at compile time, it is raised when reached
+func (c *Compiler) raise(tok token.Token, kind, msg string, args ...any) {
+    c.at(tok)
+    c.emit(OpRaise, c.constant(&object.Error{Kind: kind, Message: kind + ": " + fmt.Sprintf(msg, args...)}))
+}
+
+func (c *Compiler) statements(stmts []ast.Statement) {
+    if len(stmts) == 0 {
+        c.emit(OpNull)
+        return
+    }
+
+    for i, stmt := range stmts {
+        if i > 0 { c.emit(OpPop) }
+        c.statement(stmt)
+    }
+}
+
+// block runs bs in a scope of its own, unless it declares nothing
+func (c *Compiler) block(bs *ast.BlockStatement) {
+    elide := !slices.ContainsFunc(bs.Statements, func(stmt ast.Statement) bool { return len(ast.DeclaredNames(stmt)) > 0 })
+
+    if !elide { c.emit(OpPushScope) }
+    c.pushScope(elide)
+
+    c.statements(bs.Statements)
+
+    c.popScope()
+    if !elide { c.emit(OpPopScope) }
+}
+
+func (c *Compiler) pushScope(elide bool) {
+    c.scopes = append(c.scopes, elide)
+    c.unit().scopes++
+}
+
+func (c *Compiler) popScope() {
+    c.scopes = c.scopes[:len(c.scopes) - 1]
+    c.unit().scopes--
+}
+
+// depth converts the depth of a local from the resolver to the number of
+// environments the vm walks out, skipping elided scopes
+func (c *Compiler) depth(d int) int {
+    n := d
+    for i := 1; i <= d && i <= len(c.scopes); i++ {
+        if c.scopes[len(c.scopes) - i] { n-- }
+    }
+
+    return n
+}
+
+func (c *Compiler) statement(stmt ast.Statement) {
+    switch stmt := stmt.(type) {
+    case *ast.ExpressionStatement:
+        c.expression(stmt.Value)
+    case *ast.LetStatement:
+        c.let(stmt)
+    case *ast.ReturnStatement:
+        c.expression(stmt.Value)
+        c.emit(OpReturn)
+    case *ast.ThrowStatement:
+        c.expression(stmt.Value)
+        c.at(stmt.Token)
+        c.emit(OpThrow)
+    case *ast.BlockStatement:
+        c.block(stmt)
+    case *ast.StructStatement:
+        c.checkConstants(stmt)
+
+        def := &object.Struct{Name: stmt.Name.Value, Fields: make([]string, 0, len(stmt.Fields))}
+        for _, f := range stmt.Fields {
+            def.Fields = append(def.Fields, f.Value)
+        }
+
+        c.emit(OpStruct, c.constant(def))
+        c.emit(OpDup)
+        c.define(stmt.Name)
+    case *ast.EnumStatement:
+        c.checkConstants(stmt)
+
+        enum := object.NewEnum(stmt.Name.Value)
+        for _, v := range stmt.Variants {
+            var fields []string
+            if v.Fields != nil { fields = make([]string, 0, len(v.Fields)) }
+
+            for _, f := range v.Fields {
+                fields = append(fields, f.Value)
+            }
+            enum.AddVariant(v.Name.Value, fields)
+        }
+
+        c.emit(OpEnum, c.constant(enum))
+        c.emit(OpDup)
+        c.define(stmt.Name)
+    case *ast.ImportStatement:
+        c.checkConstants(stmt)
+
+        c.at(stmt.Token)
+        c.emit(OpImport, c.name(stmt.Path.Value))
+        c.emit(OpDup)
+        c.emit(OpDefine, c.name(stmt.Name()))
+    case *ast.ExportStatement:
+        c.statement(stmt.Statement)
+    default:
+        c.fail(stmt)
+    }
+}
+
+func (c *Compiler) let(ls *ast.LetStatement) {
+    c.checkConstants(ls)
+    c.expression(ls.Value)
+    if ls.IsConst() { c.emit(OpFreeze) }
+
+    if ls.Name != nil {
+        c.emit(OpNameFunction, c.name(ls.Name.Value))
+        c.emit(OpDup)
+        c.define(ls.Name)
+    } else {
+        c.emit(OpDup)
+        c.bindPattern(ls.Pattern, ls.Token)
+    }
+
+    if ls.IsConst() { c.emit(OpMarkConst, c.nameList(ast.DeclaredNames(ls))) }
+}
+
+// checkConstants refuses to rebind constants of the root environment, within a
+// program this is caught by the parser
+func (c *Compiler) checkConstants(stmt ast.Statement) {
+    if !c.root() { return }
+
+    c.at(stmt.Pos())
+    c.emit(OpCheckConst, c.nameList(ast.DeclaredNames(stmt)))
+}
+
+// define binds the value on top of the stack to ident, in its slot if it is local
+func (c *Compiler) define(ident *ast.Identifier) {
+    if ident.Local {
+        c.emit(OpDefineLocal, ident.Slot, c.name(ident.Value))
+        return
+    }
+    c.emit(OpDefine, c.name(ident.Value))
+}
+
+// bindPattern destructures the value on top of the stack with pat, a value which
+// does not match is reported at tok
+func (c *Compiler) bindPattern(pat ast.Pattern, tok token.Token) {
+    if ident, ok := pat.(*ast.Identifier); ok {
+        c.define(ident)
+        return
+    }
+
+    c.emit(OpDup)
+    fails := c.pattern(pat, 1, tok)
+    matched := c.emit(OpJump, NoOperand)
+
+    c.landFailures(fails)
+    c.at(tok)
+    c.emit(OpMismatch, c.name(pat.String()))
+
+    c.patch(matched)
+    c.emit(OpPop)
+}
+
+// pattern consumes the value on top of the stack, binding the names of pat as it
+// goes, n counts the values above the start of the match (including the value)
+func (c *Compiler) pattern(pat ast.Pattern, n int, tok token.Token) []failure {
+    switch pat := pat.(type) {
+    case *ast.WildcardPattern:
+        c.emit(OpPop)
+        return nil
+    case *ast.Identifier:
+        c.define(pat)
+        return nil
+    case *ast.LiteralPattern:
+        c.expression(pat.Value)
+        c.emit(OpEqual)
+        return []failure{{c.emit(OpJumpIfFalse, NoOperand), n - 1}}
+    case *ast.ArrayPattern:
+        rest := 0
+        if pat.Rest != nil { rest = 1 }
+
+        c.at(tok)
+        c.emit(OpMatchArray, len(pat.Elements), rest)
+        fails := []failure{{c.emit(OpJumpIfFalse, NoOperand), n - 1}}
+
+        above := n - 1 + len(pat.Elements) + rest
+        for _, el := range pat.Elements {
+            fails = append(fails, c.pattern(el, above, tok)...)
+            above--
+        }
+        if pat.Rest != nil { fails = append(fails, c.pattern(pat.Rest, above, tok)...) }
+
+        return fails
+    case *ast.VariantPattern:
+        if pat.Binding {
+            c.define(pat.Constructor.(*ast.Identifier))
+            return nil
+        }
+
+        c.expression(pat.Constructor)
+
+        nargs := NoOperand
+        if pat.Args != nil { nargs = len(pat.Args) }
+
+        c.at(tok)
+        c.emit(OpMatchVariant, nargs, c.name(pat.Constructor.String()))
+        fails := []failure{{c.emit(OpJumpIfFalse, NoOperand), n - 1}}
+
+        above := n - 1 + len(pat.Args)
+        for _, arg := range pat.Args {
+            fails = append(fails, c.pattern(arg, above, tok)...)
+            above--
+        }
+
+        return fails
+    default:
+        c.fail(pat)
+        return nil
+    }
+}
+
+// landFailures makes every failure continue at the next instruction once it has
+// popped its extra values
+func (c *Compiler) landFailures(fails []failure) {
+    pops := []int{}
+    for _, f := range fails {
+        if !slices.Contains(pops, f.pops) { pops = append(pops, f.pops) }
+    }
+    slices.Sort(pops)
+    slices.Reverse(pops)
+
+    for i, n := range pops {
+        for _, f := range fails {
+            if f.pops == n { c.patch(f.jump) }
+        }
+
+        next := 0
+        if i + 1 < len(pops) { next = pops[i + 1] }
+        for range n - next { c.emit(OpPop) }
+    }
+}
+
+func (c *Compiler) expression(exp ast.Expression) {
+    switch exp := exp.(type) {
+    case *ast.IntegerLiteral:
+        c.emit(OpConstant, c.constant(&object.Integer{Value: exp.Value}))
+    case *ast.FloatLiteral:
+        c.emit(OpConstant, c.constant(&object.Float{Value: exp.Value}))
+    case *ast.StringLiteral:
+        c.emit(OpConstant, c.constant(&object.String{Value: exp.Value}))
+    case *ast.BooleanLiteral:
+        if exp.Value { c.emit(OpTrue) } else { c.emit(OpFalse) }
+    case *ast.NullLiteral:
+        c.emit(OpNull)
+    case *ast.Identifier:
+        c.identifier(exp)
+    case *ast.ArrayLiteral:
+        for _, el := range exp.Elements {
+            c.expression(el)
+        }
+        c.emit(OpArray, len(exp.Elements))
+    case *ast.PrefixExpression:
+        c.expression(exp.Right)
+        c.at(exp.Token)
+        c.emit(OpPrefix, c.operator(exp, exp.Operator))
+    case *ast.InfixExpression:
+        c.expression(exp.Left)
+
+        if exp.Operator == "??" {
+            end := c.emit(OpJumpIfNotNull, NoOperand)
+            c.expression(exp.Right)
+            c.patch(end)
+            return
+        }
+
+        c.expression(exp.Right)
+        c.at(exp.Token)
+        c.emit(OpInfix, c.operator(exp, exp.Operator))
+    case *ast.IndexExpression, *ast.SliceExpression, *ast.MemberExpression, *ast.CallExpression:
+        for _, end := range c.chain(exp) { c.patch(end) }
+    case *ast.ConditionalExpression:
+        c.conditional(exp)
+    case *ast.FunctionLiteral:
+        c.function(exp)
+    case *ast.WithExpression:
+        c.with(exp)
+    case *ast.MatchExpression:
+        c.match(exp)
+    case *ast.TryExpression:
+        c.try(exp)
+    default:
+        c.fail(exp)
+    }
+}
+
+func (c *Compiler) operator(exp ast.Expression, op string) int {
+    i := slices.Index(Operators, op)
+    if i < 0 { c.fail(exp) }
+
+    return i
+}
+
+// identifier loads a local from its slot, builtins are constants as they cannot be
+// shadowed and any other name is looked up when reached
+func (c *Compiler) identifier(ident *ast.Identifier) {
+    c.at(ident.Token)
+
+    if ident.Local {
+        c.emit(OpGetLocal, c.depth(ident.Depth), ident.Slot, c.name(ident.Value))
+        return
+    }
+
+    if b, ok := eval.LookupBuiltin(ident.Value); ok {
+        i, ok := c.builtins[ident.Value]
+        if !ok {
+            i = c.constant(b)
+            c.builtins[ident.Value] = i
+        }
+
+        c.emit(OpConstant, i)
+        return
+    }
+
+    c.emit(OpGetName, c.name(ident.Value))
+}
+
+// chain compiles a postfix chain of index, slice, member and call expressions, an
+// optional index or slice that finds null jumps past the rest of the chain, leaving
+// the null as its value, these jumps are returned to be patched after the chain
+func (c *Compiler) chain(exp ast.Expression) []int {
+    var ends []int
+    left := func(exp ast.Expression, optional bool) {
+        ends = c.chain(exp)
+        if optional { ends = append(ends, c.emit(OpJumpIfNull, NoOperand)) }
+    }
+
+    switch exp := exp.(type) {
+    case *ast.IndexExpression:
+        left(exp.Left, exp.Optional)
+
+        c.expression(exp.Index)
+        c.at(exp.Token)
+        c.emit(OpIndex)
+    case *ast.SliceExpression:
+        left(exp.Left, exp.Optional)
+        c.slice(exp)
+    case *ast.MemberExpression:
+        left(exp.Left, false)
+
+        c.at(exp.Token)
+        c.emit(OpMember, c.name(exp.Member.Value))
+    case *ast.CallExpression:
+        if me, ok := exp.Function.(*ast.MemberExpression); ok {
+            left(me.Left, false)
+        } else {
+            left(exp.Function, false)
+        }
+        c.call(exp)
+    default:
+        c.expression(exp)
+    }
+
+    return ends
+}
+
+// slice compiles the bounds and slicing of se, its left side is already on the stack
+func (c *Compiler) slice(se *ast.SliceExpression) {
+    mask, k := 0, 0
+    for i, exp := range []ast.Expression{se.Start, se.End, se.Step} {
+        if exp == nil { continue }
+
+        c.expression(exp)
+        c.at(se.Token)
+        c.emit(OpSliceBound, k)
+
+        mask |= 1 << i
+        k++
+    }
+
+    c.at(se.Token)
+    c.emit(OpSlice, mask)
+}
+
+func (c *Compiler) conditional(ce *ast.ConditionalExpression) {
+    c.expression(ce.Condition)
+    c.at(ce.Token)
+    branch := c.emit(OpBranch, NoOperand, c.name(ce.Condition.String()))
+
+    c.block(ce.Consequence)
+    end := c.emit(OpJump, NoOperand)
+
+    c.patch(branch)
+    if ce.Alternative != nil {
+        c.block(ce.Alternative)
+    } else {
+        c.emit(OpNull)
+    }
+
+    c.patch(end)
+}
+
+// function compiles fl into a constant and emits the closure creating it, the body
+// starts by binding the parameters (the vm has checked the arguments against Params)
+func (c *Compiler) function(fl *ast.FunctionLiteral) {
+    c.enter(false)
+    c.pushScope(false)
+    fn := c.unit().fn
+
+    params := []string{}
+    for i, p := range fl.Parameters {
+        params = append(params, p.String())
+
+        param := Param{Default: p.Default != nil, Rest: p.Rest}
+        if ident, ok := p.Pattern.(*ast.Identifier); ok && !p.Rest { param.Name = ident.Value }
+        fn.Params = append(fn.Params, param)
+
+        // binding errors are reported at the call
+        c.at(token.Token{})
+
+        if p.Rest && i == len(fl.Parameters) - 1 {
+            c.emit(OpRest, i)
+            c.bindPattern(p.Pattern, token.Token{})
+            continue
+        }
+
+        bind := c.emit(OpParam, i, NoOperand)
+        if p.Default != nil {
+            c.expression(p.Default)
+            c.at(token.Token{})
+        } else {
+            c.emit(OpArityError)
+        }
+
+        c.patch(bind)
+        c.bindPattern(p.Pattern, token.Token{})
+    }
+
+    c.statements(fl.Body.Statements)
+    c.emit(OpReturn)
+    c.popScope()
+    c.leave()
+
+    fn.Source = "fn(" + strings.Join(params, ", ") + ")" + fl.Body.String()
+
+    c.at(fl.Token)
+    c.emit(OpClosure, c.constant(fn))
+}
+
+// call compiles the callee (or the receiver of a method) and the arguments, calls
+// with spread or named arguments describe each argument in a name list
+// call compiles the arguments and call of ce, the function (or the receiver of a
+// method call) is already on the stack
+func (c *Compiler) call(ce *ast.CallExpression) {
+    me, method := ce.Function.(*ast.MemberExpression)
+
+    kinds, plain, seen := []string{}, true, map[string]bool{}
+    for _, arg := range ce.Arguments {
+        switch arg := arg.(type) {
+        case *ast.SpreadExpression:
+            c.expression(arg.Value)
+            c.at(ce.Token)
+            c.emit(OpSpread)
+
+            kinds, plain = append(kinds, SpreadArg), false
+        case *ast.NamedArgument:
+            c.expression(arg.Value)
+            if seen[arg.Name.Value] {
+                c.raise(ce.Token, eval.InvalidArgumentError, "%s given more than once", arg.Name)
+            }
+            seen[arg.Name.Value] = true
+
+            kinds, plain = append(kinds, arg.Name.Value), false
+        default:
+            c.expression(arg)
+            kinds = append(kinds, "")
+        }
+    }
+
+    argKinds := NoOperand
+    if !plain { argKinds = c.nameList(kinds) }
+
+    c.at(ce.Token)
+    switch {
+    case method:
+        c.emit(OpCallMethod, c.name(me.Member.Value), len(ce.Arguments), argKinds)
+    case plain:
+        c.emit(OpCall, len(ce.Arguments))
+    default:
+        c.emit(OpCallArgs, len(ce.Arguments), argKinds)
+    }
+}
+
+func (c *Compiler) with(we *ast.WithExpression) {
+    c.expression(we.Left)
+    c.at(we.Token)
+    c.emit(OpWithCheck)
+
+    names := []string{}
+    for i, u := range we.Updates {
+        c.at(we.Token)
+        c.emit(OpWithField, i, c.name(u.Name.Value))
+        c.expression(u.Value)
+
+        names = append(names, u.Name.Value)
+    }
+
+    c.emit(OpWith, c.nameList(names))
+}
+
+// match keeps the subject on the stack while each arm is tried in a scope of its own
+func (c *Compiler) match(me *ast.MatchExpression) {
+    c.expression(me.Subject)
+
+    ends := []int{}
+    for _, arm := range me.Arms {
+        c.emit(OpPushScope)
+        c.pushScope(false)
+
+        c.emit(OpDup)
+        fails := c.pattern(arm.Pattern, 1, me.Token)
+
+        if arm.Guard != nil {
+            c.expression(arm.Guard)
+            c.at(me.Token)
+            fails = append(fails, failure{c.emit(OpGuard, NoOperand, c.name(arm.Guard.String())), 0})
+        }
+
+        c.statement(arm.Body)
+        c.popScope()
+        c.emit(OpPopScope)
+        c.emit(OpSwap)
+        c.emit(OpPop)
+        ends = append(ends, c.emit(OpJump, NoOperand))
+
+        c.landFailures(fails)
+        c.emit(OpPopScope)
+    }
+
+    c.at(me.Token)
+    c.emit(OpNoMatch)
+
+    for _, end := range ends {
+        c.patch(end)
+    }
+}
+
+// try compiles each block into a function of its own, the vm runs them in turn so
+// that an error or return in one of them ends only that block
+func (c *Compiler) try(te *ast.TryExpression) {
+    body := c.tryBlock(te.Body, false, nil)
+
+    catch, finally := NoOperand, NoOperand
+    if te.Catch != nil { catch = c.tryBlock(te.Catch, true, te.CatchName) }
+    if te.Finally != nil { finally = c.tryBlock(te.Finally, false, nil) }
+
+    c.at(te.Token)
+    c.emit(OpTry, body, catch, finally)
+}
+
+// tryBlock compiles bs, a catch block starts by binding the exception the vm pushes
+func (c *Compiler) tryBlock(bs *ast.BlockStatement, catch bool, name *ast.Identifier) int {
+    c.enter(false)
+    c.pushScope(false)
+
+    if catch {
+        if name != nil {
+            c.define(name)
+        } else {
+            c.emit(OpPop)
+        }
+    }
+
+    c.statements(bs.Statements)
+    c.emit(OpEnd)
+    c.popScope()
+
+    fn := c.leave()
+    fn.Source = bs.String()
+
+    return c.constant(fn)
+}
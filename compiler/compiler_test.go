@@ -0,0 +1,181 @@
+package compiler
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "testing"
+
+    "lemur/lexer"
+    "lemur/parser"
+)
+
+func TestMake(t *testing.T) {
+    tests := []struct{
+        op       Opcode
+        operands []int
+        expected []byte
+    }{
+        {OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+        {OpInfix, []int{3}, []byte{byte(OpInfix), 3}},
+        {OpCallMethod, []int{1, 2, NoOperand}, []byte{byte(OpCallMethod), 0, 1, 2, 255, 255}},
+        {OpPop, []int{}, []byte{byte(OpPop)}},
+    }
+
+    for i, tst := range tests {
+        ins := Make(tst.op, tst.operands...)
+        assert(t, i, string(ins), string(tst.expected))
+
+        def, _ := Lookup(tst.op)
+        operands, read := ReadOperands(def, ins[1:])
+        assert(t, i, read, len(ins) - 1)
+        for j, o := range operands {
+            assert(t, i, o, tst.operands[j])
+        }
+    }
+}
+
+func TestCompile(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected []string
+    }{
+        {"1 + 2", []string{
+            "0000 OpConstant 0",
+            "0003 OpConstant 1",
+            "0006 OpInfix 0",
+            "0008 OpEnd",
+        }},
+        {"let x = 1; x", []string{
+            "0000 OpCheckConst 0",
+            "0003 OpConstant 1",
+            "0006 OpNameFunction 2",
+            "0009 OpDup",
+            "0010 OpDefine 2",
+            "0013 OpPop",
+            "0014 OpGetName 2",
+            "0017 OpEnd",
+        }},
+        // blocks which declare nothing get no scope
+        {"if (true) { 1 } else { 2 }", []string{
+            "0000 OpTrue",
+            "0001 OpBranch 12 0",
+            "0006 OpConstant 1",
+            "0009 OpJump 15",
+            "0012 OpConstant 2",
+            "0015 OpEnd",
+        }},
+        {"a ?? 1", []string{
+            "0000 OpGetName 0",
+            "0003 OpJumpIfNotNull 9",
+            "0006 OpConstant 1",
+            "0009 OpEnd",
+        }},
+        {"len", []string{
+            "0000 OpConstant 0",
+            "0003 OpEnd",
+        }},
+        {"f(1, ...xs, y: 2)", []string{
+            "0000 OpGetName 0",
+            "0003 OpConstant 1",
+            "0006 OpGetName 2",
+            "0009 OpSpread",
+            "0010 OpConstant 3",
+            "0013 OpCallArgs 3 4",
+            "0017 OpEnd",
+        }},
+    }
+
+    for i, tst := range tests {
+        bc := runCompile(t, tst.input)
+        assert(t, i, bc.Main.Instructions.String(), strings.Join(tst.expected, "\n") + "\n")
+    }
+}
+
+func TestCompileFunction(t *testing.T) {
+    bc := runCompile(t, "fn(a, b = 2) { if (a) { let c = b; c } }")
+
+    fn, ok := bc.Constants[len(bc.Constants) - 1].(*Function)
+    if !ok { t.Fatalf("last constant is not a function (got %T)", bc.Constants[len(bc.Constants) - 1]) }
+
+    expected := []string{
+        "0000 OpParam 0 5",
+        "0004 OpArityError",
+        "0005 OpDefineLocal 0 0",
+        "0010 OpParam 1 17",
+        "0014 OpConstant 1",
+        "0017 OpDefineLocal 1 2",
+        "0022 OpGetLocal 0 0 0",
+        "0029 OpBranch 63 0",
+        "0034 OpPushScope",
+        "0035 OpGetLocal 1 1 2",
+        "0042 OpNameFunction 3",
+        "0045 OpDup",
+        "0046 OpDefineLocal 0 3",
+        "0051 OpPop",
+        "0052 OpGetLocal 0 0 3",
+        "0059 OpPopScope",
+        "0060 OpJump 64",
+        "0063 OpNull",
+        "0064 OpReturn",
+    }
+
+    assert(t, 0, fn.Instructions.String(), strings.Join(expected, "\n") + "\n")
+    assert(t, 0, fn.String(), "fn(a, b = 2){if a {let c = b;c;};}")
+    assert(t, 0, len(fn.Params), 2)
+    assert(t, 0, fn.Params[1], Param{Name: "b", Default: true})
+
+    // parameters are bound before the body is reached, their errors belong to the call
+    line, _ := fn.Position(0)
+    assert(t, 0, line, 0)
+    line, col := fn.Position(22)
+    assert(t, 0, line, 1)
+    assert(t, 0, col, 20)
+}
+
+// operands which do not fit their width fail to compile rather than wrap
+func TestCompileLimits(t *testing.T) {
+    var lets strings.Builder
+    for i := range 12000 { fmt.Fprintf(&lets, "let a%d = %d; ", i, i) }
+
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        {"let a = [1" + strings.Repeat(", 1", 69999) + "]; a[69999]", ProgramTooLargeError + ": constant index above 65534"},
+        {"fn() { if (true) { " + lets.String() + "} }", ProgramTooLargeError + ": jump target above 65535"},
+        {"f(1" + strings.Repeat(", 1", 255) + ")", ProgramTooLargeError + ": OpCall operand above 255"},
+    }
+
+    for i, tst := range tests {
+        p := parser.New(lexer.New(tst.input))
+        program := p.ParseProgram()
+        if len(p.Errors()) != 0 { t.Fatalf("test %d: %v", i + 1, p.Errors()) }
+
+        _, err := Compile(program)
+        if err == nil { t.Fatalf("test %d: expected an error", i + 1) }
+
+        assert(t, i, errors.Is(err, ErrTooLarge), true)
+        assert(t, i, err.Error(), tst.expected)
+    }
+}
+
+func runCompile(t *testing.T, input string) *Bytecode {
+    p := parser.New(lexer.New(input))
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0 { t.Fatalf("%q: %v", input, p.Errors()) }
+
+    bc, err := Compile(program)
+    if err != nil { t.Fatalf("%q: %s", input, err) }
+
+    return bc
+}
+
+func assert(t *testing.T, testIdx int, val, expected any) {
+    if val != expected {
+        t.Errorf("test %d: incorrect value, expected %T: %v (got %T: %v)",
+            testIdx + 1,
+            expected, expected,
+            val, val)
+    }
+}
@@ -1,18 +1,54 @@
 package eval
 
-import "lemur/object"
+import (
+    "maps"
+    "math"
+    "slices"
+    "strings"
+
+    "lemur/object"
+)
 
 const (
-    Len   = "len"
-    First = "first"
-    Last  = "last"
-    Head  = "head"
-    Tail  = "tail"
-    Push  = "push"
+    Len     = "len"
+    First   = "first"
+    Last    = "last"
+    Head    = "head"
+    Tail    = "tail"
+    Push    = "push"
+    Map     = "map"
+    Filter  = "filter"
+    Reduce  = "reduce"
+    Any     = "any"
+    All     = "all"
+    Find    = "find"
+    Sort    = "sort"
+    SortBy  = "sort_by"
+    Range   = "range"
+    Collect = "collect"
 )
 
-var builtins = map[string]object.Builtin{
-    Len: func(args ...object.Object) object.Object {
+var builtins = collectBuiltins(coreBuiltins, stringBuiltins, typeBuiltins)
+
+// BuiltinNames lists the builtins, they are looked up before any declared name
+func BuiltinNames() []string { return slices.Sorted(maps.Keys(builtins)) }
+
+// PreludeNames lists the prelude globals, they are looked up after every declared name
+func PreludeNames() []string { return slices.Sorted(maps.Keys(prelude)) }
+
+func collectBuiltins(groups ...map[string]object.BuiltinFunction) map[string]*object.Builtin {
+    all := make(map[string]*object.Builtin)
+    for _, group := range groups {
+        for name, fn := range group {
+            all[name] = &object.Builtin{Name: name, Fn: fn}
+        }
+    }
+
+    return all
+}
+
+var coreBuiltins = map[string]object.BuiltinFunction{
+    Len: func(_ object.Applier, args ...object.Object) object.Object {
         if len(args) != 1 {
             return createError(ArgumentMistmatchError, "%s", Len)
         }
@@ -22,11 +58,21 @@ var builtins = map[string]object.Builtin{
             return &object.Integer{Value: int64(len(input.Elements))}
         case *object.String:
             return &object.Integer{Value: int64(len(input.Value))}
+        case *object.Range:
+            if input.Len() > math.MaxInt64 { return createError(IntegerOverflowError, "%s(%s)", Len, input) }
+            return &object.Integer{Value: int64(input.Len())}
+        case *object.Iterator:
+            n := int64(0)
+            for el := range input.All() {
+                if isError(el) { return el }
+                n++
+            }
+            return &object.Integer{Value: n}
         default:
             return createError(ArgumentTypesError, "%s(%s)", Len,  input.Type())
         }
     },
-    First: func(args ...object.Object) object.Object {
+    First: func(_ object.Applier, args ...object.Object) object.Object {
         if len(args) != 1 {
             return createError(ArgumentMistmatchError, "%s", First)
         }
@@ -42,7 +88,7 @@ var builtins = map[string]object.Builtin{
             return createError(ArgumentTypesError, "%s(%s)", First,  input.Type())
         }
     },
-    Last: func(args ...object.Object) object.Object {
+    Last: func(_ object.Applier, args ...object.Object) object.Object {
         if len(args) != 1 {
             return createError(ArgumentMistmatchError, "%s", Last)
         }
@@ -58,7 +104,7 @@ var builtins = map[string]object.Builtin{
             return createError(ArgumentTypesError, "%s(%s)", Last,  input.Type())
         }
     },
-    Head: func(args ...object.Object) object.Object {
+    Head: func(_ object.Applier, args ...object.Object) object.Object {
         if len(args) != 1 {
             return createError(ArgumentMistmatchError, "%s", Head)
         }
@@ -66,7 +112,7 @@ var builtins = map[string]object.Builtin{
         switch input := args[0].(type) {
         case *object.Array:
             if len(input.Elements) < 2 { return &object.Array{Elements: []object.Object{}} }
-            return &object.Array{Elements: input.Elements[0:len(input.Elements) - 1]}
+            return input.Slice(0, len(input.Elements) - 1)
         case *object.String:
             if len(input.Value) < 2 { return &object.String{Value: ""} }
             return &object.String{Value: string(input.Value[0:len(input.Value) - 1])}
@@ -74,7 +120,7 @@ var builtins = map[string]object.Builtin{
             return createError(ArgumentTypesError, "%s(%s)", Head,  input.Type())
         }
     },
-    Tail: func(args ...object.Object) object.Object {
+    Tail: func(_ object.Applier, args ...object.Object) object.Object {
         if len(args) != 1 {
             return createError(ArgumentMistmatchError, "%s", Tail)
         }
@@ -82,7 +128,7 @@ var builtins = map[string]object.Builtin{
         switch input := args[0].(type) {
         case *object.Array:
             if len(input.Elements) < 2 { return &object.Array{Elements: []object.Object{}} }
-            return &object.Array{Elements: input.Elements[1:len(input.Elements)]}
+            return input.Slice(1, len(input.Elements))
         case *object.String:
             if len(input.Value) < 2 { return &object.String{Value: ""} }
             return &object.String{Value: string(input.Value[1:len(input.Value)])}
@@ -90,7 +136,7 @@ var builtins = map[string]object.Builtin{
             return createError(ArgumentTypesError, "%s(%s)", Tail, input.Type())
         }
     },
-    Push: func(args ...object.Object) object.Object {
+    Push: func(_ object.Applier, args ...object.Object) object.Object {
         if len(args) != 2 {
             return createError(ArgumentMistmatchError, "%s", Push)
         }
@@ -106,8 +152,7 @@ var builtins = map[string]object.Builtin{
                     Push, input.Elements[0].Type(), args[1].Type())
             }
 
-            arr := append(input.Elements, args[1])
-            return &object.Array{Elements: arr}
+            return input.Push(args[1])
         case *object.String:
             obj, ok := args[1].(*object.String)
             if !ok {
@@ -122,4 +167,270 @@ var builtins = map[string]object.Builtin{
                 Push, input.Type(), args[1].Type())
         }
     },
+    Map: func(apply object.Applier, args ...object.Object) object.Object {
+        seq, f, err := callbackArgs(Map, args)
+        if err != nil { return err }
+
+        arr, ok := seq.(*object.Array)
+        if !ok {
+            return &object.Iterator{Seq: func(yield func(object.Object) bool) {
+                for el := range seq.All() {
+                    res := el
+                    if !isError(el) { res = apply(f, el) }
+                    if !yield(res) || isError(res) { return }
+                }
+            }}
+        }
+
+        elems := make([]object.Object, 0, len(arr.Elements))
+        for _, el := range arr.Elements {
+            res := apply(f, el)
+            if isError(res) { return res }
+
+            elems = append(elems, res)
+        }
+
+        return &object.Array{Elements: elems}
+    },
+    Filter: func(apply object.Applier, args ...object.Object) object.Object {
+        seq, f, err := callbackArgs(Filter, args)
+        if err != nil { return err }
+
+        arr, ok := seq.(*object.Array)
+        if !ok {
+            return &object.Iterator{Seq: func(yield func(object.Object) bool) {
+                for el := range seq.All() {
+                    if isError(el) { yield(el); return }
+
+                    keep, err := applyPredicate(apply, Filter, f, el)
+                    if err != nil { yield(err); return }
+
+                    if keep && !yield(el) { return }
+                }
+            }}
+        }
+
+        elems := []object.Object{}
+        for _, el := range arr.Elements {
+            keep, err := applyPredicate(apply, Filter, f, el)
+            if err != nil { return err }
+
+            if keep { elems = append(elems, el) }
+        }
+
+        return &object.Array{Elements: elems}
+    },
+    Reduce: func(apply object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 && len(args) != 3 {
+            return createError(ArgumentMistmatchError, "%s", Reduce)
+        }
+
+        seq, f, err := callbackArgs(Reduce, args[:2])
+        if err != nil { return err }
+
+        var acc object.Object
+        if len(args) == 3 { acc = args[2] }
+
+        for el := range seq.All() {
+            if isError(el) { return el }
+            if acc == nil {
+                acc = el
+                continue
+            }
+
+            acc = apply(f, acc, el)
+            if isError(acc) { return acc }
+        }
+
+        if acc == nil { return Null }
+        return acc
+    },
+    Any: func(apply object.Applier, args ...object.Object) object.Object {
+        seq, f, err := callbackArgs(Any, args)
+        if err != nil { return err }
+
+        for el := range seq.All() {
+            ok, err := applyPredicate(apply, Any, f, el)
+            if err != nil { return err }
+
+            if ok { return True }
+        }
+
+        return False
+    },
+    All: func(apply object.Applier, args ...object.Object) object.Object {
+        seq, f, err := callbackArgs(All, args)
+        if err != nil { return err }
+
+        for el := range seq.All() {
+            ok, err := applyPredicate(apply, All, f, el)
+            if err != nil { return err }
+
+            if !ok { return False }
+        }
+
+        return True
+    },
+    Find: func(apply object.Applier, args ...object.Object) object.Object {
+        seq, f, err := callbackArgs(Find, args)
+        if err != nil { return err }
+
+        for el := range seq.All() {
+            ok, err := applyPredicate(apply, Find, f, el)
+            if err != nil { return err }
+
+            if ok { return el }
+        }
+
+        return Null
+    },
+    Sort: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", Sort)
+        }
+
+        seq, ok := args[0].(object.Iterable)
+        if !ok {
+            return createError(ArgumentTypesError, "%s(%s)", Sort, args[0].Type())
+        }
+
+        elems, err := collectIterable(seq)
+        if err != nil { return err }
+
+        return sortArray(elems, elems)
+    },
+    SortBy: func(apply object.Applier, args ...object.Object) object.Object {
+        seq, f, err := callbackArgs(SortBy, args)
+        if err != nil { return err }
+
+        elems, errObj := collectIterable(seq)
+        if errObj != nil { return errObj }
+
+        keys := make([]object.Object, 0, len(elems))
+        for _, el := range elems {
+            key := apply(f, el)
+            if isError(key) { return key }
+
+            keys = append(keys, key)
+        }
+
+        return sortArray(elems, keys)
+    },
+    Range: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) < 1 || len(args) > 3 {
+            return createError(ArgumentMistmatchError, "%s", Range)
+        }
+
+        bounds := []int64{0, 0, 1}
+        for i, a := range args {
+            n, ok := a.(*object.Integer)
+            if !ok {
+                return createError(ArgumentTypesError, "%s(%s)", Range, argTypes(args))
+            }
+            bounds[i] = n.Value
+        }
+        if len(args) == 1 { bounds[0], bounds[1] = 0, bounds[0] }
+
+        if bounds[2] == 0 {
+            return createError(InvalidArgumentError, "%s step cannot be zero", Range)
+        }
+
+        return &object.Range{Start: bounds[0], End: bounds[1], Step: bounds[2]}
+    },
+    Collect: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", Collect)
+        }
+
+        seq, ok := args[0].(object.Iterable)
+        if !ok {
+            return createError(ArgumentTypesError, "%s(%s)", Collect, args[0].Type())
+        }
+
+        elems, err := collectIterable(seq)
+        if err != nil { return err }
+
+        return &object.Array{Elements: elems}
+    },
+}
+
+func argTypes(args []object.Object) string {
+    types := make([]string, 0, len(args))
+    for _, a := range args {
+        types = append(types, string(a.Type()))
+    }
+
+    return strings.Join(types, ", ")
+}
+
+func isCallable(obj object.Object) bool {
+    switch obj.Type() {
+    case object.FunctionType, object.BuiltinType, object.StructType:
+        return true
+    default:
+        return false
+    }
+}
+
+// callbackArgs validates arguments of the form (Iterable, callable)
+func callbackArgs(name string, args []object.Object) (object.Iterable, object.Object, *object.Error) {
+    if len(args) != 2 {
+        return nil, nil, createError(ArgumentMistmatchError, "%s", name)
+    }
+
+    seq, ok := args[0].(object.Iterable)
+    if !ok || !isCallable(args[1]) {
+        return nil, nil, createError(ArgumentTypesError, "%s(%s, %s)", name, args[0].Type(), args[1].Type())
+    }
+
+    return seq, args[1], nil
+}
+
+// collectIterable materializes seq, stopping at the first error produced by a lazy sequence
+func collectIterable(seq object.Iterable) ([]object.Object, object.Object) {
+    if arr, ok := seq.(*object.Array); ok { return arr.Elements, nil }
+
+    elems := []object.Object{}
+    for el := range seq.All() {
+        if isError(el) { return nil, el }
+        elems = append(elems, el)
+    }
+
+    return elems, nil
+}
+
+func applyPredicate(apply object.Applier, name string, f object.Object, el object.Object) (bool, object.Object) {
+    if isError(el) { return false, el }
+
+    res := apply(f, el)
+    if isError(res) { return false, res }
+
+    if res.Type() != object.BooleanType {
+        return false, createError(InvalidConditionError, "%s callback returned %s", name, res.Type())
+    }
+
+    return res == True, nil
+}
+
+// sortArray stably sorts elems by the value at the same index in keys
+func sortArray(elems, keys []object.Object) object.Object {
+    order := make([]int, len(elems))
+    for i := range order { order[i] = i }
+
+    var err object.Object
+    slices.SortStableFunc(order, func(a, b int) int {
+        res, ok := object.Compare(keys[a], keys[b])
+        if !ok && err == nil {
+            err = createError(NotOrderedError, "%s, %s", keys[a], keys[b])
+        }
+        return res
+    })
+    if err != nil { return err }
+
+    sorted := make([]object.Object, 0, len(elems))
+    for _, i := range order {
+        sorted = append(sorted, elems[i])
+    }
+
+    return &object.Array{Elements: sorted}
 }
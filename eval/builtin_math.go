@@ -0,0 +1,206 @@
+package eval
+
+import (
+    "math"
+
+    "lemur/object"
+)
+
+const (
+    MathNamespace = "math"
+
+    Abs   = "abs"
+    Min   = "min"
+    Max   = "max"
+    Pow   = "pow"
+    Sqrt  = "sqrt"
+    Floor = "floor"
+    Ceil  = "ceil"
+    Round = "round"
+    Clamp = "clamp"
+    Gcd   = "gcd"
+    Pi    = "PI"
+    E     = "E"
+)
+
+var mathNamespace = func() *object.Namespace {
+    members := map[string]object.Object{
+        Pi: &object.Float{Value: math.Pi},
+        E: &object.Float{Value: math.E},
+    }
+    for name, fn := range mathBuiltins {
+        members[name] = &object.Builtin{Name: mathName(name), Fn: fn}
+    }
+
+    return &object.Namespace{Name: MathNamespace, Members: members}
+}()
+
+var mathBuiltins = map[string]object.BuiltinFunction{
+    Abs: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", mathName(Abs))
+        }
+
+        switch n := args[0].(type) {
+        case *object.Integer:
+            if n.Value == math.MinInt64 { return createError(IntegerOverflowError, "%s(%d)", mathName(Abs), n.Value) }
+            if n.Value < 0 { return &object.Integer{Value: -n.Value} }
+            return n
+        case *object.Float:
+            return &object.Float{Value: math.Abs(n.Value)}
+        default:
+            return createError(ArgumentTypesError, "%s(%s)", mathName(Abs), n.Type())
+        }
+    },
+    Min: mathExtremum(Min, -1),
+    Max: mathExtremum(Max, 1),
+    Pow: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 {
+            return createError(ArgumentMistmatchError, "%s", mathName(Pow))
+        }
+
+        base, baseOk := args[0].(*object.Integer)
+        exp, expOk := args[1].(*object.Integer)
+        if baseOk && expOk && exp.Value >= 0 {
+            res, ok := powInt(base.Value, exp.Value)
+            if !ok { return createError(IntegerOverflowError, "%s(%d, %d)", mathName(Pow), base.Value, exp.Value) }
+
+            return &object.Integer{Value: res}
+        }
+
+        nums, err := numericArgs(Pow, args, 2)
+        if err != nil { return err }
+
+        return &object.Float{Value: math.Pow(nums[0], nums[1])}
+    },
+    Sqrt: mathFloatFunction(Sqrt, math.Sqrt),
+    Floor: mathRoundingFunction(Floor, math.Floor),
+    Ceil: mathRoundingFunction(Ceil, math.Ceil),
+    Round: mathRoundingFunction(Round, math.Round),
+    Clamp: func(_ object.Applier, args ...object.Object) object.Object {
+        nums, err := numericArgs(Clamp, args, 3)
+        if err != nil { return err }
+
+        if nums[1] > nums[2] {
+            return createError(InvalidArgumentError, "%s bounds %s > %s", mathName(Clamp), args[1], args[2])
+        }
+
+        switch {
+        case nums[0] < nums[1]:
+            return args[1]
+        case nums[0] > nums[2]:
+            return args[2]
+        default:
+            return args[0]
+        }
+    },
+    Gcd: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 {
+            return createError(ArgumentMistmatchError, "%s", mathName(Gcd))
+        }
+
+        a, aOk := args[0].(*object.Integer)
+        b, bOk := args[1].(*object.Integer)
+        if !aOk || !bOk {
+            return createError(ArgumentTypesError, "%s(%s)", mathName(Gcd), argTypes(args))
+        }
+
+        x, y := a.Value, b.Value
+        for y != 0 { x, y = y, x % y }
+        if x == math.MinInt64 { return createError(IntegerOverflowError, "%s(%d, %d)", mathName(Gcd), a.Value, b.Value) }
+        if x < 0 { x = -x }
+
+        return &object.Integer{Value: x}
+    },
+}
+
+func mathName(name string) string { return MathNamespace + "." + name }
+
+// numericArgs validates that exactly n Integer or Float arguments were passed
+func numericArgs(name string, args []object.Object, n int) ([]float64, *object.Error) {
+    if len(args) != n {
+        return nil, createError(ArgumentMistmatchError, "%s", mathName(name))
+    }
+
+    nums := make([]float64, 0, n)
+    for _, a := range args {
+        f, ok := object.ToFloat(a)
+        if !ok {
+            return nil, createError(ArgumentTypesError, "%s(%s)", mathName(name), argTypes(args))
+        }
+        nums = append(nums, f)
+    }
+
+    return nums, nil
+}
+
+func mathFloatFunction(name string, f func(float64) float64) object.BuiltinFunction {
+    return func(_ object.Applier, args ...object.Object) object.Object {
+        nums, err := numericArgs(name, args, 1)
+        if err != nil { return err }
+
+        return &object.Float{Value: f(nums[0])}
+    }
+}
+
+// mathRoundingFunction rounds floats to the nearest Integer in the direction given by f
+func mathRoundingFunction(name string, f func(float64) float64) object.BuiltinFunction {
+    return func(_ object.Applier, args ...object.Object) object.Object {
+        nums, err := numericArgs(name, args, 1)
+        if err != nil { return err }
+
+        if n, ok := args[0].(*object.Integer); ok { return n }
+
+        n, ok := floatToInt(f(nums[0]))
+        if !ok { return createError(ConversionError, "%s(%s) to %s", mathName(name), args[0], object.IntegerType) }
+
+        return &object.Integer{Value: n}
+    }
+}
+
+// mathExtremum returns the argument which compares as sign against all others
+func mathExtremum(name string, sign int) object.BuiltinFunction {
+    return func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) == 0 {
+            return createError(ArgumentMistmatchError, "%s", mathName(name))
+        }
+
+        best := args[0]
+        for _, a := range args {
+            if !isNumeric(a) {
+                return createError(ArgumentTypesError, "%s(%s)", mathName(name), argTypes(args))
+            }
+
+            res, _ := object.Compare(a, best)
+            if res == sign { best = a }
+        }
+
+        return best
+    }
+}
+
+// powInt raises base to exp (exp >= 0) by squaring, it reports false on overflow
+func powInt(base, exp int64) (int64, bool) {
+    res := int64(1)
+    ok := true
+
+    for exp > 0 {
+        if exp & 1 == 1 {
+            if res, ok = mulInt(res, base); !ok { return 0, false }
+        }
+
+        exp >>= 1
+        if exp > 0 {
+            if base, ok = mulInt(base, base); !ok { return 0, false }
+        }
+    }
+
+    return res, true
+}
+
+func mulInt(a, b int64) (int64, bool) {
+    res := a * b
+    if a != 0 && (res / a != b || a == -1 && b == math.MinInt64) { return 0, false }
+
+    return res, true
+}
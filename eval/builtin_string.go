@@ -0,0 +1,226 @@
+package eval
+
+import (
+    "strings"
+    "unicode/utf8"
+
+    "lemur/object"
+)
+
+const (
+    Split      = "split"
+    Join       = "join"
+    Trim       = "trim"
+    TrimLeft   = "trim_left"
+    TrimRight  = "trim_right"
+    Upper      = "upper"
+    Lower      = "lower"
+    Replace    = "replace"
+    Contains   = "contains"
+    StartsWith = "starts_with"
+    EndsWith   = "ends_with"
+    IndexOf    = "index_of"
+    Repeat     = "repeat"
+    PadLeft    = "pad_left"
+    PadRight   = "pad_right"
+    Chars      = "chars"
+)
+
+// MaxStringLength bounds the strings built by repeat and padding (in bytes)
+const MaxStringLength = 1 << 28
+
+var stringBuiltins = map[string]object.BuiltinFunction{
+    Split: func(_ object.Applier, args ...object.Object) object.Object {
+        strs, err := stringArgs(Split, args, 2)
+        if err != nil { return err }
+
+        parts := strings.Split(strs[0], strs[1])
+        return createStringArray(parts)
+    },
+    Join: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 {
+            return createError(ArgumentMistmatchError, "%s", Join)
+        }
+
+        arr, ok := args[0].(*object.Array)
+        sep, sepOk := args[1].(*object.String)
+        if !ok || !sepOk {
+            return createError(ArgumentTypesError, "%s(%s)", Join, argTypes(args))
+        }
+
+        parts := make([]string, 0, len(arr.Elements))
+        for _, el := range arr.Elements {
+            str, ok := el.(*object.String)
+            if !ok {
+                return createError(ArgumentTypesError, "%s(Array[%s], String)", Join, el.Type())
+            }
+            parts = append(parts, str.Value)
+        }
+
+        return &object.String{Value: strings.Join(parts, sep.Value)}
+    },
+    Trim: stringTransform(Trim, strings.TrimSpace),
+    TrimLeft: stringTransform(TrimLeft, func(s string) string {
+        return strings.TrimLeft(s, " \t\n\r")
+    }),
+    TrimRight: stringTransform(TrimRight, func(s string) string {
+        return strings.TrimRight(s, " \t\n\r")
+    }),
+    Upper: stringTransform(Upper, strings.ToUpper),
+    Lower: stringTransform(Lower, strings.ToLower),
+    Replace: func(_ object.Applier, args ...object.Object) object.Object {
+        strs, err := stringArgs(Replace, args, 3)
+        if err != nil { return err }
+
+        return &object.String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}
+    },
+    Contains: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 {
+            return createError(ArgumentMistmatchError, "%s", Contains)
+        }
+
+        if arr, ok := args[0].(*object.Array); ok {
+            return createBooleanObject(indexOfElement(arr, args[1]) != -1)
+        }
+
+        strs, err := stringArgs(Contains, args, 2)
+        if err != nil { return err }
+
+        return createBooleanObject(strings.Contains(strs[0], strs[1]))
+    },
+    StartsWith: func(_ object.Applier, args ...object.Object) object.Object {
+        strs, err := stringArgs(StartsWith, args, 2)
+        if err != nil { return err }
+
+        return createBooleanObject(strings.HasPrefix(strs[0], strs[1]))
+    },
+    EndsWith: func(_ object.Applier, args ...object.Object) object.Object {
+        strs, err := stringArgs(EndsWith, args, 2)
+        if err != nil { return err }
+
+        return createBooleanObject(strings.HasSuffix(strs[0], strs[1]))
+    },
+    IndexOf: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 {
+            return createError(ArgumentMistmatchError, "%s", IndexOf)
+        }
+
+        if arr, ok := args[0].(*object.Array); ok {
+            return &object.Integer{Value: int64(indexOfElement(arr, args[1]))}
+        }
+
+        strs, err := stringArgs(IndexOf, args, 2)
+        if err != nil { return err }
+
+        return &object.Integer{Value: int64(strings.Index(strs[0], strs[1]))}
+    },
+    Repeat: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 {
+            return createError(ArgumentMistmatchError, "%s", Repeat)
+        }
+
+        str, ok := args[0].(*object.String)
+        n, nOk := args[1].(*object.Integer)
+        if !ok || !nOk {
+            return createError(ArgumentTypesError, "%s(%s)", Repeat, argTypes(args))
+        }
+        if n.Value < 0 {
+            return createError(InvalidArgumentError, "%s count %d", Repeat, n.Value)
+        }
+        if n.Value > 0 && int64(len(str.Value)) > MaxStringLength / n.Value {
+            return createError(InvalidArgumentError, "%s count %d exceeds the maximum string length", Repeat, n.Value)
+        }
+
+        return &object.String{Value: strings.Repeat(str.Value, int(n.Value))}
+    },
+    PadLeft: stringPad(PadLeft, func(s, pad string) string { return pad + s }),
+    PadRight: stringPad(PadRight, func(s, pad string) string { return s + pad }),
+    Chars: func(_ object.Applier, args ...object.Object) object.Object {
+        strs, err := stringArgs(Chars, args, 1)
+        if err != nil { return err }
+
+        chars := []string{}
+        for _, r := range strs[0] {
+            chars = append(chars, string(r))
+        }
+
+        return createStringArray(chars)
+    },
+}
+
+// stringArgs validates that exactly n string arguments were passed
+func stringArgs(name string, args []object.Object, n int) ([]string, *object.Error) {
+    if len(args) != n {
+        return nil, createError(ArgumentMistmatchError, "%s", name)
+    }
+
+    strs := make([]string, 0, n)
+    for _, a := range args {
+        str, ok := a.(*object.String)
+        if !ok {
+            return nil, createError(ArgumentTypesError, "%s(%s)", name, argTypes(args))
+        }
+        strs = append(strs, str.Value)
+    }
+
+    return strs, nil
+}
+
+func stringTransform(name string, f func(string) string) object.BuiltinFunction {
+    return func(_ object.Applier, args ...object.Object) object.Object {
+        strs, err := stringArgs(name, args, 1)
+        if err != nil { return err }
+
+        return &object.String{Value: f(strs[0])}
+    }
+}
+
+// stringPad pads a string to a width (in characters) with an optional pad string (default " ")
+func stringPad(name string, join func(s, pad string) string) object.BuiltinFunction {
+    return func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 && len(args) != 3 {
+            return createError(ArgumentMistmatchError, "%s", name)
+        }
+
+        str, ok := args[0].(*object.String)
+        width, widthOk := args[1].(*object.Integer)
+        pad := &object.String{Value: " "}
+        padOk := true
+        if len(args) == 3 { pad, padOk = args[2].(*object.String) }
+
+        if !ok || !widthOk || !padOk {
+            return createError(ArgumentTypesError, "%s(%s)", name, argTypes(args))
+        }
+        if pad.Value == "" {
+            return createError(InvalidArgumentError, "%s with empty padding", name)
+        }
+
+        if width.Value > MaxStringLength {
+            return createError(InvalidArgumentError, "%s width %d exceeds the maximum string length", name, width.Value)
+        }
+
+        missing := int(width.Value) - utf8.RuneCountInString(str.Value)
+        if missing <= 0 { return str }
+
+        count := (missing + utf8.RuneCountInString(pad.Value) - 1) / utf8.RuneCountInString(pad.Value)
+        padRunes := []rune(strings.Repeat(pad.Value, count))
+        return &object.String{Value: join(str.Value, string(padRunes[:missing]))}
+    }
+}
+
+func createStringArray(strs []string) *object.Array {
+    elems := make([]object.Object, 0, len(strs))
+    for _, s := range strs {
+        elems = append(elems, &object.String{Value: s})
+    }
+
+    return &object.Array{Elements: elems}
+}
+
+func indexOfElement(arr *object.Array, obj object.Object) int {
+    for i, el := range arr.Elements {
+        if object.Equal(el, obj) { return i }
+    }
+
+    return -1
+}
@@ -0,0 +1,162 @@
+package eval
+
+import (
+    "math"
+    "strconv"
+
+    "lemur/object"
+)
+
+const (
+    Type      = "type"
+    Str       = "str"
+    Int       = "int"
+    Float     = "float"
+    Bool      = "bool"
+    IsString  = "is_string"
+    IsInt     = "is_int"
+    IsFloat   = "is_float"
+    IsBool    = "is_bool"
+    IsArray   = "is_array"
+    IsFn      = "is_fn"
+    IsNull    = "is_null"
+    IsVariant = "is_variant"
+    IsFrozen  = "is_frozen"
+)
+
+var typeBuiltins = map[string]object.BuiltinFunction{
+    Type: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", Type)
+        }
+
+        if rec, ok := args[0].(*object.Record); ok { return &object.String{Value: rec.Def.TypeName()} }
+        return &object.String{Value: string(args[0].Type())}
+    },
+    Str: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", Str)
+        }
+
+        if str, ok := args[0].(*object.String); ok { return str }
+        return &object.String{Value: args[0].String()}
+    },
+    Int: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", Int)
+        }
+
+        switch input := args[0].(type) {
+        case *object.Integer:
+            return input
+        case *object.Float:
+            n, ok := floatToInt(input.Value)
+            if !ok { return createError(ConversionError, "%s to %s", input, object.IntegerType) }
+
+            return &object.Integer{Value: n}
+        case *object.Boolean:
+            if input.Value { return &object.Integer{Value: 1} }
+            return &object.Integer{Value: 0}
+        case *object.String:
+            val, err := strconv.ParseInt(input.Value, 10, 64)
+            if err != nil {
+                return createError(ConversionError, "%q to %s", input.Value, object.IntegerType)
+            }
+            return &object.Integer{Value: val}
+        default:
+            return createError(ArgumentTypesError, "%s(%s)", Int, input.Type())
+        }
+    },
+    Float: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", Float)
+        }
+
+        switch input := args[0].(type) {
+        case *object.Float:
+            return input
+        case *object.Integer:
+            return &object.Float{Value: float64(input.Value)}
+        case *object.String:
+            val, err := strconv.ParseFloat(input.Value, 64)
+            if err != nil {
+                return createError(ConversionError, "%q to %s", input.Value, object.FloatType)
+            }
+            return &object.Float{Value: val}
+        default:
+            return createError(ArgumentTypesError, "%s(%s)", Float, input.Type())
+        }
+    },
+    Bool: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", Bool)
+        }
+
+        switch input := args[0].(type) {
+        case *object.Boolean:
+            return input
+        case *object.Integer:
+            return createBooleanObject(input.Value != 0)
+        case *object.Null:
+            return False
+        case *object.String:
+            switch input.Value {
+            case "true": return True
+            case "false": return False
+            default:
+                return createError(ConversionError, "%q to %s", input.Value, object.BooleanType)
+            }
+        default:
+            return createError(ArgumentTypesError, "%s(%s)", Bool, input.Type())
+        }
+    },
+    IsString: typePredicate(IsString, object.StringType),
+    IsInt: typePredicate(IsInt, object.IntegerType),
+    IsFloat: typePredicate(IsFloat, object.FloatType),
+    IsBool: typePredicate(IsBool, object.BooleanType),
+    IsArray: typePredicate(IsArray, object.ArrayType),
+    IsFn: typePredicate(IsFn, object.FunctionType, object.BuiltinType),
+    IsNull: typePredicate(IsNull, object.NullType),
+    IsVariant: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 2 {
+            return createError(ArgumentMistmatchError, "%s", IsVariant)
+        }
+
+        // constructors match any record they build, singletons are compared directly
+        if def, ok := args[1].(*object.Struct); ok {
+            rec, ok := args[0].(*object.Record)
+            return createBooleanObject(ok && rec.Def == def)
+        }
+        return createBooleanObject(object.Equal(args[0], args[1]))
+    },
+    IsFrozen: func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", IsFrozen)
+        }
+
+        arr, ok := args[0].(*object.Array)
+        return createBooleanObject(ok && arr.Frozen)
+    },
+}
+
+func typePredicate(name string, types ...object.ObjectType) object.BuiltinFunction {
+    return func(_ object.Applier, args ...object.Object) object.Object {
+        if len(args) != 1 {
+            return createError(ArgumentMistmatchError, "%s", name)
+        }
+
+        for _, t := range types {
+            if args[0].Type() == t { return True }
+        }
+        return False
+    }
+}
+
+// floatToInt truncates f, it reports false for NaN, infinities and floats out of the
+// range of an Integer
+func floatToInt(f float64) (int64, bool) {
+    f = math.Trunc(f)
+    if !(f >= math.MinInt64 && f < -math.MinInt64) { return 0, false }
+
+    return int64(f), true
+}
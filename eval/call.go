@@ -0,0 +1,141 @@
+package eval
+
+import (
+    "fmt"
+    "maps"
+    "slices"
+
+    "lemur/ast"
+    "lemur/object"
+)
+
+// evalArguments evaluates call arguments, expanding spreads into the positional
+// arguments and collecting named arguments by parameter name
+func evalArguments(exps []ast.Expression, env *object.Environment) ([]object.Object, map[string]object.Object, object.Object) {
+    args := make([]object.Object, 0, len(exps))
+    var named map[string]object.Object
+
+    for _, e := range exps {
+        switch e := e.(type) {
+        case *ast.SpreadExpression:
+            obj := Eval(e.Value, env)
+            if isError(obj) { return nil, nil, obj }
+
+            seq, ok := obj.(object.Iterable)
+            if !ok { return nil, nil, createError(InvalidArgumentError, "cannot spread %s", obj.Type()) }
+
+            elems, err := collectIterable(seq)
+            if err != nil { return nil, nil, err }
+
+            args = append(args, elems...)
+        case *ast.NamedArgument:
+            obj := Eval(e.Value, env)
+            if isError(obj) { return nil, nil, obj }
+
+            if named == nil { named = map[string]object.Object{} }
+            if _, ok := named[e.Name.Value]; ok {
+                return nil, nil, createError(InvalidArgumentError, "%s given more than once", e.Name)
+            }
+            named[e.Name.Value] = obj
+        default:
+            obj := Eval(e, env)
+            if isError(obj) { return nil, nil, obj }
+
+            args = append(args, obj)
+        }
+    }
+
+    return args, named, nil
+}
+
+// callFunction is applyFunction with support for named arguments (user functions and
+// struct constructors only)
+func callFunction(fn object.Object, args []object.Object, named map[string]object.Object) object.Object {
+    if len(named) == 0 { return applyFunction(fn, args...) }
+
+    if def, ok := fn.(*object.Struct); ok { return constructRecord(def, args, named) }
+
+    f, ok := fn.(*object.Function)
+    if !ok { return createError(InvalidArgumentError, "%s does not accept named arguments", fn.Type()) }
+
+    innerEnv := object.CreateEnclosedEnvironment(f.OuterEnv)
+    if err := bindParameters(f, innerEnv, args, named); err != nil { return err }
+
+    return unwrapReturn(evalBlock(f.Body.Statements, innerEnv))
+}
+
+// bindParameters binds args and named to the parameters of f in env,
+// parameters without an argument take their default, evaluated after earlier parameters
+func bindParameters(f *object.Function, env *object.Environment, args []object.Object, named map[string]object.Object) object.Object {
+    params, rest := f.Parameters, (*ast.Parameter)(nil)
+    if n := len(params); n > 0 && params[n - 1].Rest { params, rest = params[:n - 1], params[n - 1] }
+
+    if len(args) > len(params) && rest == nil { return arityError(f, len(args) + len(named)) }
+
+    names := make([]string, len(params))
+    for i, p := range params {
+        if ident, ok := p.Pattern.(*ast.Identifier); ok { names[i] = ident.Value }
+    }
+    for _, name := range slices.Sorted(maps.Keys(named)) {
+        if !slices.Contains(names, name) {
+            return createError(InvalidArgumentError, "%s has no parameter %s", functionName(f), name)
+        }
+    }
+
+    for i, p := range params {
+        var val object.Object
+
+        name := names[i]
+        namedVal, isNamed := named[name]
+
+        switch {
+        case i < len(args):
+            if isNamed {
+                return createError(InvalidArgumentError, "%s got more than one value for %s", functionName(f), name)
+            }
+            val = args[i]
+        case isNamed:
+            val = namedVal
+        case p.Default != nil:
+            val = Eval(p.Default, env)
+            if isError(val) { return val }
+        default:
+            return arityError(f, len(args) + len(named))
+        }
+
+        if ok, err := matchPattern(p.Pattern, val, env); !ok {
+            if err != nil { return err }
+            return createError(PatternMismatchError, "%s = %s", p.Pattern, val)
+        }
+    }
+
+    if rest != nil {
+        extra := []object.Object{}
+        if len(args) > len(params) { extra = slices.Clone(args[len(params):]) }
+
+        bind(rest.Pattern.(*ast.Identifier), &object.Array{Elements: extra}, env)
+    }
+
+    return nil
+}
+
+func arityError(f *object.Function, got int) *object.Error {
+    required, total := 0, 0
+    for _, p := range f.Parameters {
+        if p.Rest { continue }
+
+        total++
+        if p.Default == nil { required++ }
+    }
+
+    expected := fmt.Sprint(total)
+    if required != total { expected = fmt.Sprintf("%d to %d", required, total) }
+    if total != len(f.Parameters) { expected = fmt.Sprintf("at least %d", required) }
+
+    return createError(ArgumentMistmatchError, "%s expects %s, got %d", functionName(f), expected, got)
+}
+
+func functionName(f *object.Function) string {
+    if f.Name == "" { return "fn" }
+    return f.Name
+}
@@ -2,21 +2,36 @@ package eval
 
 import (
     "fmt"
+    "math"
 
     "lemur/ast"
     "lemur/object"
+    "lemur/resolver"
 )
 
 const (
     ArgumentMistmatchError      = "wrong number of arguments for function"
     ArgumentTypesError          = "argument type(s) not supported"
+    ConversionError             = "invalid conversion"
+    ConstReassignmentError      = "cannot reassign constant"
     IndexOutOfBoundsError       = "index out of bounds"
     IdentifierNotFoundError     = "identifier not found"
     InfixNotImplementedError    = "no infixes implemented for type"
+    IntegerOverflowError        = "integer overflow"
+    InvalidArgumentError        = "invalid argument"
     InvalidConditionError       = "invalid condition"
     InvalidCastError            = "invalid type cast"
     InvalidIndexExpressionError = "invalid index expression"
+    InvalidSliceError           = "invalid slice"
+    InvalidUpdateError          = "invalid record update"
+    InvalidMemberAccessError    = "invalid member access"
+    InvalidPatternError         = "invalid pattern"
+    MemberNotFoundError         = "member not found"
+    MethodNotFoundError         = "method not found"
+    NoMatchError                = "no match arm for value"
     NotYetImplementedError      = "not yet implemented"
+    PatternMismatchError        = "value does not match pattern"
+    NotOrderedError             = "values cannot be ordered"
     TypeMismatchError           = "type mismatch"
     UnknownOperatorError        = "unknown operator"
     UnknownASTNodeError         = "unknown AST node"
@@ -29,11 +44,27 @@ var (
     Null = &object.Null{}
 )
 
-
+// Eval evaluates node in env, errors are positioned at the innermost node that
+// produced them
 func Eval(node ast.Node, env *object.Environment) object.Object {
+    return at(node, evalNode(node, env))
+}
+
+// at positions an error produced by node
+func at(node ast.Node, obj object.Object) object.Object {
+    if err, ok := obj.(*object.Error); ok {
+        tok := node.Pos()
+        return err.At(tok.Line, tok.Col)
+    }
+
+    return obj
+}
+
+func evalNode(node ast.Node, env *object.Environment) object.Object {
     switch node := node.(type) {
 
     case ast.Program:
+        resolve(node)
         return evalBlock(node, env)
 
     case *ast.BlockStatement:
@@ -41,11 +72,22 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
         return evalBlock(node.Statements, innerEnv)
 
     case *ast.LetStatement:
-        obj := Eval(node.Value, env)
-        if isError(obj) { return obj }
+        return evalLetStatement(node, env)
 
-        env.Set(node.Name.Value, obj)
-        return obj
+    case *ast.ThrowStatement:
+        return evalThrowStatement(node, env)
+
+    case *ast.StructStatement:
+        return evalStructStatement(node, env)
+
+    case *ast.EnumStatement:
+        return evalEnumStatement(node, env)
+
+    case *ast.ImportStatement:
+        return evalImportStatement(node, env)
+
+    case *ast.ExportStatement:
+        return Eval(node.Statement, env)
 
     case *ast.ReturnStatement:
         obj := Eval(node.Value, env)
@@ -60,29 +102,30 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
         return &object.Function{Parameters: node.Parameters, Body: node.Body, OuterEnv: env}
 
     case *ast.CallExpression:
-        obj := Eval(node.Function, env)
-        if isError(obj) { return obj }
-
-        switch f := obj.(type) {
-        case *object.Function:
-            return evalFunction(f, node.Arguments, env)
-        case object.Builtin:
-            return evalBuiltin(f, node.Arguments, env)
-
-        default:
-            return createError(
-                InvalidCastError + InternalErrorPostfix,
-                "%T cannot be cast to object.Function",
-                obj)
-        }
+        obj, _ := evalChain(node, env)
+        return obj
 
     case *ast.ConditionalExpression:
         return evalConditionalExpression(node, env)
 
+    case *ast.MatchExpression:
+        return evalMatchExpression(node, env)
+
+    case *ast.TryExpression:
+        return evalTryExpression(node, env)
+
+    case *ast.WithExpression:
+        return evalWithExpression(node, env)
+
     case *ast.InfixExpression:
         left := Eval(node.Left, env)
         if isError(left) { return left }
 
+        if node.Operator == "??" {
+            if left != Null { return left }
+            return Eval(node.Right, env)
+        }
+
         right := Eval(node.Right, env)
         if isError(right) { return right }
 
@@ -110,7 +153,16 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
         return arr
 
     case *ast.IndexExpression:
-        return evalIndexExpression(node.Left, node.Index, env)
+        obj, _ := evalChain(node, env)
+        return obj
+
+    case *ast.SliceExpression:
+        obj, _ := evalChain(node, env)
+        return obj
+
+    case *ast.MemberExpression:
+        obj, _ := evalChain(node, env)
+        return obj
 
     case *ast.StringLiteral:
         return &object.String{Value: node.Value}
@@ -118,14 +170,57 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
     case *ast.IntegerLiteral:
         return &object.Integer{Value: node.Value}
 
+    case *ast.FloatLiteral:
+        return &object.Float{Value: node.Value}
+
     case *ast.BooleanLiteral:
         return createBooleanObject(node.Value)
 
+    case *ast.NullLiteral:
+        return Null
+
     default:
         return createError(UnknownASTNodeError + InternalErrorPostfix, "%T", node)
     }
 }
 
+func evalLetStatement(ls *ast.LetStatement, env *object.Environment) object.Object {
+    if err := checkConstants(ls, env); err != nil { return err }
+
+    obj := Eval(ls.Value, env)
+    if isError(obj) { return obj }
+
+    // constants bind a frozen copy, the names they destructure are part of it
+    if ls.IsConst() { obj = object.Freeze(obj) }
+
+    if ls.Pattern != nil {
+        if ok, err := matchPattern(ls.Pattern, obj, env); !ok {
+            if err != nil { return err }
+            return createError(PatternMismatchError, "%s = %s", ls.Pattern, obj)
+        }
+    } else {
+        if fn, ok := obj.(*object.Function); ok && fn.Name == "" {
+            fn.Name = ls.Name.Value
+        }
+        bind(ls.Name, obj, env)
+    }
+
+    if ls.IsConst() {
+        for _, name := range ast.DeclaredNames(ls) { env.MarkConst(name) }
+    }
+
+    return obj
+}
+
+// checkConstants reports an error if stmt would rebind a constant of the current scope,
+// the parser catches this within a single program but not across REPL inputs
+func checkConstants(stmt ast.Statement, env *object.Environment) object.Object {
+    for _, name := range ast.DeclaredNames(stmt) {
+        if env.IsConst(name) { return createError(ConstReassignmentError, "%s", name) }
+    }
+    return nil
+}
+
 func evalBlock(block []ast.Statement, env *object.Environment) object.Object {
     if len(block) == 0 { return Null } // no-op
     var obj object.Object
@@ -139,33 +234,39 @@ func evalBlock(block []ast.Statement, env *object.Environment) object.Object {
     return obj
 }
 
-func evalBuiltin(f object.Builtin, argExprs []ast.Expression, env *object.Environment) object.Object {
-    args := []object.Object{}
+func evalExpressions(exps []ast.Expression, env *object.Environment) ([]object.Object, object.Object) {
+    objs := make([]object.Object, 0, len(exps))
 
-    for _, a := range argExprs {
-        o := Eval(a, env)
-        if isError(o) { return o }
+    for _, e := range exps {
+        o := Eval(e, env)
+        if isError(o) { return nil, o }
 
-        args = append(args, o)
+        objs = append(objs, o)
     }
 
-    return f(args...)
+    return objs, nil
 }
 
-func evalFunction(f *object.Function, args []ast.Expression, env *object.Environment) object.Object {
-    if len(args) != len(f.Parameters) {
-        return createError(ArgumentMistmatchError, "%s", f)
-    }
+func applyFunction(fn object.Object, args ...object.Object) object.Object {
+    switch f := fn.(type) {
+    case *object.Function:
+        innerEnv := object.CreateEnclosedEnvironment(f.OuterEnv)
+        if err := bindParameters(f, innerEnv, args, nil); err != nil { return err }
 
-    innerEnv := object.CreateEnclosedEnvironment(f.OuterEnv)
-    for i, a := range args {
-        o := Eval(a, env)
-        if isError(o) { return o }
+        return unwrapReturn(evalBlock(f.Body.Statements, innerEnv))
 
-        innerEnv.Set(f.Parameters[i].Value, o)
-    }
+    case *object.Builtin:
+        return f.Fn(applyFunction, args...)
+
+    case *object.Struct:
+        return constructRecord(f, args, nil)
 
-    return unwrapReturn(evalBlock(f.Body.Statements, innerEnv))
+    default:
+        return createError(
+            InvalidCastError + InternalErrorPostfix,
+            "%T cannot be cast to object.Function",
+            fn)
+    }
 }
 
 func evalConditionalExpression(ce *ast.ConditionalExpression, env *object.Environment) object.Object {
@@ -181,33 +282,105 @@ func evalConditionalExpression(ce *ast.ConditionalExpression, env *object.Enviro
     return createError(InvalidConditionError, "%s", ce.Condition)
 }
 
-func evalIndexExpression(left, index ast.Expression, env *object.Environment) object.Object {
-    leftObj := Eval(left, env)
-    if isError(leftObj) { return leftObj }
+// evalChain evaluates a postfix chain of index, slice, member and call expressions
+// link by link, short reports that an optional index or slice found null, which
+// skips the rest of the chain ('a?[0][1]' is null when a is)
+func evalChain(exp ast.Expression, env *object.Environment) (obj object.Object, short bool) {
+    switch exp := exp.(type) {
+    case *ast.IndexExpression:
+        left, done := evalChainLeft(exp.Left, exp.Optional, env)
+        if done { return left, left == Null }
+
+        return at(exp, evalIndexExpression(exp, left, env)), false
+    case *ast.SliceExpression:
+        left, done := evalChainLeft(exp.Left, exp.Optional, env)
+        if done { return left, left == Null }
+
+        return at(exp, evalSliceExpression(exp, left, env)), false
+    case *ast.MemberExpression:
+        left, done := evalChainLeft(exp.Left, false, env)
+        if done { return left, left == Null }
+
+        return at(exp, lookupMember(left, exp.Member.Value)), false
+    case *ast.CallExpression:
+        if me, ok := exp.Function.(*ast.MemberExpression); ok {
+            recv, done := evalChainLeft(me.Left, false, env)
+            if done { return recv, recv == Null }
+
+            return at(exp, evalMethodCall(me, recv, exp.Arguments, env)), false
+        }
+
+        fn, done := evalChainLeft(exp.Function, false, env)
+        if done { return fn, fn == Null }
+
+        args, named, err := evalArguments(exp.Arguments, env)
+        if err != nil { return err, false }
+
+        return at(exp, callFunction(fn, args, named)), false
+    default:
+        return Eval(exp, env), false
+    }
+}
+
+// evalChainLeft evaluates the left side of a link in a postfix chain, done reports
+// that the link evaluates to obj, an error or the null an optional link found
+func evalChainLeft(left ast.Expression, optional bool, env *object.Environment) (obj object.Object, done bool) {
+    obj, short := evalChain(left, env)
+    return obj, short || isError(obj) || optional && obj == Null
+}
 
-    indexObj := Eval(index, env)
+func evalIndexExpression(ie *ast.IndexExpression, leftObj object.Object, env *object.Environment) object.Object {
+    indexObj := Eval(ie.Index, env)
     if isError(indexObj) { return indexObj }
 
+    return indexObject(leftObj, indexObj)
+}
+
+func indexObject(leftObj, indexObj object.Object) object.Object {
     switch {
     case leftObj.Type() == object.ArrayType && indexObj.Type() == object.IntegerType:
         arr := leftObj.(*object.Array)
         idx := indexObj.(*object.Integer).Value
 
-        if idx < 0 || idx > int64(len(arr.Elements)) - 1 {
-            return createError(IndexOutOfBoundsError, "%d", idx)
-        }
+        i, ok := normalizeIndex(idx, len(arr.Elements))
+        if !ok { return createError(IndexOutOfBoundsError, "%d", idx) }
 
-        return arr.Elements[idx]
+        return arr.Elements[i]
 
     case leftObj.Type() == object.StringType && indexObj.Type() == object.IntegerType:
         str := leftObj.(*object.String)
         idx := indexObj.(*object.Integer).Value
 
-        if idx < 0 || idx > int64(len(str.Value)) - 1 {
-            return createError(IndexOutOfBoundsError, "%d", idx)
+        i, ok := normalizeIndex(idx, len(str.Value))
+        if !ok { return createError(IndexOutOfBoundsError, "%d", idx) }
+
+        return &object.String{Value: string(str.Value[i])}
+
+    case leftObj.Type() == object.RangeType && indexObj.Type() == object.IntegerType:
+        rng := leftObj.(*object.Range)
+        idx := indexObj.(*object.Integer).Value
+
+        // negating in uint64 keeps the magnitude of the smallest integer
+        i, n := uint64(idx), rng.Len()
+        if idx < 0 {
+            if -i > n { return createError(IndexOutOfBoundsError, "%d", idx) }
+            i = n - -i
+        }
+        if i >= n { return createError(IndexOutOfBoundsError, "%d", idx) }
+
+        return rng.At(i)
+
+    case leftObj.Type() == object.IteratorType && indexObj.Type() == object.IntegerType:
+        idx := indexObj.(*object.Integer).Value
+        if idx < 0 { return createError(IndexOutOfBoundsError, "%d", idx) }
+
+        i := int64(0)
+        for el := range leftObj.(*object.Iterator).All() {
+            if isError(el) || i == idx { return el }
+            i++
         }
 
-        return &object.String{Value: string(str.Value[idx])}
+        return createError(IndexOutOfBoundsError, "%d", idx)
 
     default:
         return createError(
@@ -217,7 +390,183 @@ func evalIndexExpression(left, index ast.Expression, env *object.Environment) ob
     }
 }
 
+// negative indices count back from the end of the sequence
+func normalizeIndex(idx int64, length int) (int, bool) {
+    if idx < 0 { idx += int64(length) }
+    if idx < 0 || idx >= int64(length) { return 0, false }
+
+    return int(idx), true
+}
+
+func lookupMember(obj object.Object, name string) object.Object {
+    switch obj := obj.(type) {
+    case *object.Namespace:
+        member, ok := obj.Members[name]
+        if !ok { return createError(MemberNotFoundError, "%s.%s", obj.Name, name) }
+
+        return member
+    case *object.Module:
+        member, ok := obj.Exports[name]
+        if !ok { return createError(MemberNotFoundError, "%s.%s", obj.Name, name) }
+
+        return member
+    case *object.Enum:
+        member, ok := obj.Members[name]
+        if !ok { return createError(MemberNotFoundError, "%s.%s", obj.Name, name) }
+
+        return member
+    case *object.Record:
+        field, ok := obj.Get(name)
+        if !ok { return createError(MemberNotFoundError, "%s.%s", obj.Def.Name, name) }
+
+        return field
+    case *object.Exception:
+        return exceptionMember(obj, name)
+    default:
+        return createError(InvalidMemberAccessError, "%s.%s", obj.Type(), name)
+    }
+}
+
+// evalMethodCall calls members of namespaces and modules directly, on any other receiver
+// recv.f(args) is sugar for f(recv, args) where f is a builtin or a function in scope
+func evalMethodCall(me *ast.MemberExpression, recv object.Object, argExps []ast.Expression, env *object.Environment) object.Object {
+    args, named, err := evalArguments(argExps, env)
+    if err != nil { return err }
+
+    switch recv := recv.(type) {
+    case *object.Namespace, *object.Module, *object.Enum:
+        fn := lookupMember(recv, me.Member.Value)
+        if isError(fn) { return fn }
+
+        return callFunction(fn, args, named)
+    case *object.Record:
+        // a callable field takes precedence over a function of the same name
+        if field, ok := recv.Get(me.Member.Value); ok && isCallable(field) {
+            return callFunction(field, args, named)
+        }
+    }
+
+    fn := lookupMethod(me.Member.Value, env)
+    if fn == nil { return createError(MethodNotFoundError, "%s.%s", recv.Type(), me.Member.Value) }
+
+    return callFunction(fn, append([]object.Object{recv}, args...), named)
+}
+
+func lookupMethod(name string, env *object.Environment) object.Object {
+    if b, ok := builtins[name]; ok { return b }
+    if obj, ok := env.Get(name); ok && isCallable(obj) { return obj }
+
+    return nil
+}
+
+func evalSliceExpression(se *ast.SliceExpression, leftObj object.Object, env *object.Environment) object.Object {
+    bounds := [3]*int64{}
+    for i, exp := range []ast.Expression{se.Start, se.End, se.Step} {
+        if exp == nil { continue }
+
+        obj := Eval(exp, env)
+        if isError(obj) { return obj }
+
+        n, err := sliceBound(leftObj, obj)
+        if err != nil { return err }
+        bounds[i] = &n
+    }
+
+    return sliceObject(leftObj, bounds)
+}
+
+func sliceBound(leftObj, bound object.Object) (int64, *object.Error) {
+    n, ok := bound.(*object.Integer)
+    if !ok {
+        return 0, createError(
+            InvalidIndexExpressionError,
+            "cannot slice %s with %s",
+            leftObj.Type(), bound.Type())
+    }
+    return n.Value, nil
+}
+
+func sliceObject(leftObj object.Object, bounds [3]*int64) object.Object {
+    switch left := leftObj.(type) {
+    case *object.Array:
+        indices, err := sliceIndices(len(left.Elements), bounds[0], bounds[1], bounds[2])
+        if err != nil { return err }
+
+        elems := make([]object.Object, 0, len(indices))
+        for _, i := range indices {
+            elems = append(elems, left.Elements[i])
+        }
+        // like object.Array.Slice, slices of a frozen array are frozen
+        return &object.Array{Elements: elems, Frozen: left.Frozen}
+
+    case *object.String:
+        indices, err := sliceIndices(len(left.Value), bounds[0], bounds[1], bounds[2])
+        if err != nil { return err }
+
+        buf := make([]byte, 0, len(indices))
+        for _, i := range indices {
+            buf = append(buf, left.Value[i])
+        }
+        return &object.String{Value: string(buf)}
+
+    default:
+        return createError(InvalidIndexExpressionError, "cannot slice %s", leftObj.Type())
+    }
+}
+
+// sliceIndices follows python semantics: missing bounds default to the ends
+// of the sequence (which depend on the sign of step) and out of range bounds are clamped
+func sliceIndices(length int, start, end, step *int64) ([]int, *object.Error) {
+    n := int64(length)
+
+    st := int64(1)
+    if step != nil { st = *step }
+    if st == 0 { return nil, createError(InvalidSliceError, "step cannot be zero") }
+
+    lo, hi := int64(0), n
+    if st < 0 { lo, hi = -1, n - 1 }
+
+    bound := func(b *int64, def int64) int64 {
+        if b == nil { return def }
+
+        v := *b
+        if v < 0 { v += n }
+        return min(max(v, lo), hi)
+    }
+
+    var from, to int64
+    if st > 0 {
+        from, to = bound(start, 0), bound(end, n)
+    } else {
+        from, to = bound(start, n - 1), bound(end, -1)
+    }
+
+    // the count is worked out first as stepping past the end may overflow
+    var count uint64
+    if st > 0 && from < to {
+        count = uint64(to - from - 1) / uint64(st) + 1
+    } else if st < 0 && from > to {
+        count = uint64(from - to - 1) / -uint64(st) + 1
+    }
+
+    indices := make([]int, 0, count)
+    for k := range count {
+        indices = append(indices, int(from + int64(k) * st))
+    }
+
+    return indices, nil
+}
+
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
+    if operator == ".." || operator == "..=" {
+        return evalRangeExpression(operator, left, right)
+    }
+    if left.Type() == object.NullType || right.Type() == object.NullType {
+        return evalEqualityExpression(operator, left, right)
+    }
+    if isNumeric(left) && isNumeric(right) && left.Type() != right.Type() {
+        left, right = toFloatObject(left), toFloatObject(right)
+    }
     if left.Type() != right.Type() {
         return createError(TypeMismatchError, "%s %s %s", left.Type(), operator, right.Type())
     }
@@ -227,13 +576,69 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
         return evalStringInfixExpression(operator, left, right)
     case left.Type() == object.IntegerType:
         return evalIntegerInfixExpression(operator, left, right)
+    case left.Type() == object.FloatType:
+        return evalFloatInfixExpression(operator, left, right)
     case left.Type() == object.BooleanType:
         return evalBooleanInfixExpression(operator, left, right)
+    case left.Type() == object.ArrayType:
+        return evalArrayInfixExpression(operator, left, right)
+    case left.Type() == object.FunctionType || left.Type() == object.BuiltinType,
+        left.Type() == object.RangeType || left.Type() == object.IteratorType,
+        left.Type() == object.ModuleType || left.Type() == object.ExceptionType,
+        left.Type() == object.StructType || left.Type() == object.RecordType,
+        left.Type() == object.EnumType:
+        return evalEqualityExpression(operator, left, right)
     default:
         return createError(InfixNotImplementedError, "%s", left.Type())
     }
 }
 
+func evalRangeExpression(operator string, left, right object.Object) object.Object {
+    start, ok := left.(*object.Integer)
+    end, endOk := right.(*object.Integer)
+    if !ok || !endOk {
+        return createError(UnknownOperatorError, "%s %s %s", left.Type(), operator, right.Type())
+    }
+
+    if operator == "..=" {
+        if end.Value == math.MaxInt64 { return createError(IntegerOverflowError, "%s ..= %s", start, end) }
+        return &object.Range{Start: start.Value, End: end.Value + 1, Step: 1}
+    }
+    return &object.Range{Start: start.Value, End: end.Value, Step: 1}
+}
+
+func evalEqualityExpression(operator string, left, right object.Object) object.Object {
+    switch operator {
+    case "==":
+        return createBooleanObject(object.Equal(left, right))
+    case "!=":
+        return createBooleanObject(!object.Equal(left, right))
+    default:
+        return createError(UnknownOperatorError, "%s %s %s", left.Type(), operator, right.Type())
+    }
+}
+
+func evalArrayInfixExpression(operator string, left, right object.Object) object.Object {
+    switch operator {
+    case "==", "!=":
+        return evalEqualityExpression(operator, left, right)
+    case "<", ">":
+        return evalOrderingExpression(operator, left, right)
+    default:
+        return createError(UnknownOperatorError, "%s %s %s", left.Type(), operator, right.Type())
+    }
+}
+
+func evalOrderingExpression(operator string, left, right object.Object) object.Object {
+    res, ok := object.Compare(left, right)
+    if !ok {
+        return createError(NotOrderedError, "%s %s %s", left, operator, right)
+    }
+
+    if operator == "<" { return createBooleanObject(res < 0) }
+    return createBooleanObject(res > 0)
+}
+
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
     leftVal := left.(*object.String).Value
     rightVal := right.(*object.String).Value
@@ -241,6 +646,10 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
     switch operator {
     case "+":
         return &object.String{Value: leftVal + rightVal}
+    case "<":
+        return createBooleanObject(leftVal < rightVal)
+    case ">":
+        return createBooleanObject(leftVal > rightVal)
     case "==":
         return createBooleanObject(leftVal == rightVal)
     case "!=":
@@ -248,7 +657,6 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
     default:
         return createError(UnknownOperatorError, "%s %s %s", left.Type(), operator, right.Type())
     }
-
 }
 
 func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
@@ -277,6 +685,32 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
     }
 }
 
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+    leftVal := left.(*object.Float).Value
+    rightVal := right.(*object.Float).Value
+
+    switch operator {
+    case "+":
+        return &object.Float{Value: leftVal + rightVal}
+    case "-":
+        return &object.Float{Value: leftVal - rightVal}
+    case "*":
+        return &object.Float{Value: leftVal * rightVal}
+    case "/":
+        return &object.Float{Value: leftVal / rightVal}
+    case "<":
+        return createBooleanObject(leftVal < rightVal)
+    case ">":
+        return createBooleanObject(leftVal > rightVal)
+    case "==":
+        return createBooleanObject(leftVal == rightVal)
+    case "!=":
+        return createBooleanObject(leftVal != rightVal)
+    default:
+        return createError(UnknownOperatorError, "%s %s %s", left.Type(), operator, right.Type())
+    }
+}
+
 func evalBooleanInfixExpression(operator string, left, right object.Object) object.Object {
     leftVal := left.(*object.Boolean).Value
     rightVal := right.(*object.Boolean).Value
@@ -318,21 +752,45 @@ func evalBangPrefix(right object.Object) object.Object {
 }
 
 func evalMinusPrefix(right object.Object) object.Object {
-    if right.Type() != object.IntegerType {
+    switch right := right.(type) {
+    case *object.Integer:
+        return &object.Integer{Value: -right.Value}
+    case *object.Float:
+        return &object.Float{Value: -right.Value}
+    default:
         return createError(UnknownOperatorError, "-%s", right.Type())
     }
-    
-    val := right.(*object.Integer).Value
-    return &object.Integer{Value: -val}
 }
 
+// resolve annotates the local variables of program with their slots, its diagnostics
+// are only reported by `lemur check`
+func resolve(program ast.Program) {
+    resolver.New(BuiltinNames(), PreludeNames()).Resolve(program)
+}
+
+// evalIdentifier looks up node in the same order as the resolver, locals are never
+// builtins and fall back to a lookup by name if their slot is not set yet
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-    if b, ok := builtins[node.Value]; ok { return b }
+    if node.Local {
+        if obj, ok := env.GetAt(node.Depth, node.Slot, node.Value); ok { return obj }
+    } else if b, ok := builtins[node.Value]; ok {
+        return b
+    }
     if obj, ok := env.Get(node.Value); ok { return obj }
+    if obj, ok := prelude[node.Value]; ok { return obj }
 
     return createError(IdentifierNotFoundError, "%s", node.Value)
 }
 
+// bind declares ident in env, in its slot if it is a local variable
+func bind(ident *ast.Identifier, val object.Object, env *object.Environment) {
+    if ident.Local {
+        env.SetAt(ident.Slot, ident.Value, val)
+        return
+    }
+    env.Set(ident.Value, val)
+}
+
 
 func unwrapReturn(obj object.Object) object.Object {
     if ret, ok := obj.(*object.Return); ok { return ret.Value }
@@ -344,7 +802,16 @@ func createBooleanObject(val bool) object.Object{
 }
 
 func createError(errKind string, msg string, args ...any) *object.Error {
-    return &object.Error{Message: errKind + ": " + fmt.Sprintf(msg, args...)}
+    return &object.Error{Kind: errKind, Message: errKind + ": " + fmt.Sprintf(msg, args...)}
+}
+
+func isNumeric(obj object.Object) bool {
+    return obj.Type() == object.IntegerType || obj.Type() == object.FloatType
+}
+
+func toFloatObject(obj object.Object) object.Object {
+    if n, ok := obj.(*object.Integer); ok { return &object.Float{Value: float64(n.Value)} }
+    return obj
 }
 
 func isError(obj object.Object) bool { return obj.Type() == object.ErrorType }
@@ -3,6 +3,7 @@ package eval
 import (
     "testing"
 
+    "lemur/ast"
     "lemur/lexer"
     "lemur/parser"
     "lemur/object"
@@ -21,7 +22,7 @@ func TestLetStatement(t *testing.T) {
 
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         res := assertCast[*object.Integer](t, i, obj)
         assert(t, i, res.Value, tst.expected)
@@ -43,7 +44,7 @@ func TestReturnStatement(t *testing.T) {
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         ret := assertCast[*object.Return](t, i, obj)
         n := assertCast[*object.Integer](t, i, ret.Value)
@@ -94,7 +95,7 @@ func TestBuiltinFunction(t *testing.T) { // these should use builtin constants
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         switch expd := tst.expected.(type) {
         case int:
@@ -115,6 +116,440 @@ func TestBuiltinFunction(t *testing.T) { // these should use builtin constants
     }
 }
 
+func TestHigherOrderBuiltins(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"map([1, 2, 3], fn(x) { x * 2 })", []int{2, 4, 6}},
+        {"map([], fn(x) { x * 2 })", []int{}},
+        {"map([[1], [1, 2]], len)", []int{1, 2}},
+        {"filter([1, 2, 3, 4], fn(x) { x > 2 })", []int{3, 4}},
+        {"reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)", 16},
+        {"reduce([1, 2, 3], fn(acc, x) { acc * x })", 6},
+        {"reduce([], fn(acc, x) { acc + x })", nil},
+        {"reduce([[1], [2, 3]], fn(acc, x) { acc + len(x) }, 0)", 3},
+        {"any([1, 2, 3], fn(x) { x == 2 })", true},
+        {"any([], fn(x) { true })", false},
+        {"all([1, 2, 3], fn(x) { x > 0 })", true},
+        {"all([1, 2, 3], fn(x) { x > 1 })", false},
+        {"find([1, 2, 3], fn(x) { x > 1 })", 2},
+        {"find([1, 2, 3], fn(x) { x > 5 })", nil},
+        {"sort([3, 1, 2])", []int{1, 2, 3}},
+        {"sort([])", []int{}},
+        {"first(sort([[2, 1], [1, 5], [1]]))", []int{1}},
+        {"sort_by([3, 1, 2], fn(x) { -x })", []int{3, 2, 1}},
+        {"map(sort_by([[1, 2, 3], [1], [1, 2]], len), len)", []int{1, 2, 3}},
+        {"let arr = [5, 4]; sort(arr); arr", []int{5, 4}},
+        {"let add = fn(n) { fn(x) { x + n } }; map([1, 2], add(10))", []int{11, 12}},
+        {"map([1, 2], fn(x) { x })", []int{1, 2}},
+        {"map(1, fn(x) { x })", ArgumentTypesError + ": map(Integer, Function)"},
+        {"map([1], 1)", ArgumentTypesError + ": map(Array, Integer)"},
+        {"map([1])", ArgumentMistmatchError + ": map"},
+        {"map([1], fn(x) { x + true })", TypeMismatchError + ": Integer + Boolean"},
+        {"filter([1], fn(x) { x })", InvalidConditionError + ": filter callback returned Integer"},
+        {"reduce([1], fn(x) { x }, 1, 2)", ArgumentMistmatchError + ": reduce"},
+        {"sort([1, true])", NotOrderedError + ": true, 1"},
+        {"sort(1)", ArgumentTypesError + ": sort(Integer)"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case []int:
+            arr := assertCast[*object.Array](t, i, obj)
+            assertMsg(t, i, len(arr.Elements), len(expd), "wrong number of elements")
+            for idx, el := range arr.Elements {
+                res := assertCast[*object.Integer](t, i, el)
+                assert(t, i, res.Value, int64(expd[idx]))
+            }
+        case string:
+            res := assertCast[*object.Error](t, i, obj)
+            assert(t, i, res.Message, expd)
+        case nil:
+            assert(t, i, obj, Null)
+        }
+    }
+}
+
+func TestRangeExpression(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"collect(1..5)", []int{1, 2, 3, 4}},
+        {"collect(1..=5)", []int{1, 2, 3, 4, 5}},
+        {"collect(5..1)", []int{}},
+        {"collect(range(3))", []int{0, 1, 2}},
+        {"collect(range(0, 10, 3))", []int{0, 3, 6, 9}},
+        {"collect(range(5, 0, -2))", []int{5, 3, 1}},
+        {"len(0..1000000)", 1000000},
+        {"len(range(0, 10, 3))", 4},
+        {"len(range(10, 0, -3))", 4},
+        {"len(5..1)", 0},
+        {"(0..1000000)[999999]", 999999},
+        {"(0..10)[-1]", 9},
+        {"range(0, 10, 2)[2]", 4},
+        {"reduce(1..=100, fn(a, b) { a + b })", 5050},
+        {"reduce(0..100000, fn(a, b) { a + 1 }, 0)", 100000},
+        {"collect(map(1..4, fn(x) { x * x }))", []int{1, 4, 9}},
+        {"collect(filter(1..10, fn(x) { x > 7 }))", []int{8, 9}},
+        {"len(filter(0..100, fn(x) { x < 10 }))", 10},
+        {"map(0..1000000, fn(x) { x * 2 })[3]", 6},
+        {"type(map(1..2, fn(x) { x }))", "Iterator"},
+        {"type(1..2)", "Range"},
+        {"str(1..3)", "1..3"},
+        {"str(range(0, 6, 2))", "range(0, 6, 2)"},
+        {"any(1..1000000, fn(x) { x == 3 })", true},
+        {"all(1..5, fn(x) { x < 3 })", false},
+        {"find(map(1..100, fn(x) { x * x }), fn(x) { x > 50 })", 64},
+        {"sort(range(5, 0, -1))", []int{1, 2, 3, 4, 5}},
+        {"sort_by(1..4, fn(x) { -x })", []int{3, 2, 1}},
+        {"let m = map(1..3, fn(x) { x + 1 }); collect(m) == collect(m)", true},
+        {"1..3 == range(1, 3)", true},
+        {"1..3 == 1..4", false},
+        {"let n = 3; collect(0..n + 1)", []int{0, 1, 2, 3}},
+        {"len(0..9223372036854775807)", 9223372036854775807},
+        {"len(-9223372036854775807 - 1..-1)", 9223372036854775807},
+        {"len(range(0, 10, 9223372036854775807))", 1},
+        {"range(0, 10, 9223372036854775807)[0]", 0},
+        {"range(0, 10, 9223372036854775807)[-1]", 0},
+        {"collect(range(9223372036854775807, -9223372036854775807 - 1, -9223372036854775807 - 1))", []int{9223372036854775807, -1}},
+        {"(-9223372036854775807 - 1..9223372036854775807)[-1]", 9223372036854775806},
+        {"(0..9223372036854775807)[-9223372036854775807]", 0},
+        {"len(0..=9223372036854775806)", 9223372036854775807},
+        {"(-9223372036854775807 - 1..=0)[0]", -9223372036854775808},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            res := assertCast[*object.String](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case []int:
+            arr := assertCast[*object.Array](t, i, obj)
+            assertMsg(t, i, len(arr.Elements), len(expd), "wrong number of elements")
+            for idx, el := range arr.Elements {
+                res := assertCast[*object.Integer](t, i, el)
+                assert(t, i, res.Value, int64(expd[idx]))
+            }
+        }
+    }
+
+    errorTests := []struct{
+        input    string
+        expected string
+    }{
+        {"(0..3)[3]", IndexOutOfBoundsError + ": 3"},
+        {"(0..3)[-4]", IndexOutOfBoundsError + ": -4"},
+        {"(0..3)[-9223372036854775807 - 1]", IndexOutOfBoundsError + ": -9223372036854775808"},
+        {"len(-9223372036854775807..9223372036854775807)", IntegerOverflowError + ": len(-9223372036854775807..9223372036854775807)"},
+        {"0..=9223372036854775807", IntegerOverflowError + ": 0 ..= 9223372036854775807"},
+        {"map(0..3, fn(x) { x })[5]", IndexOutOfBoundsError + ": 5"},
+        {"collect(map(0..3, fn(x) { x + true }))", TypeMismatchError + ": Integer + Boolean"},
+        {"len(filter(0..3, fn(x) { x }))", InvalidConditionError + ": filter callback returned Integer"},
+        {"collect(map(map(0..3, fn(x) { x + true }), fn(x) { x }))", TypeMismatchError + ": Integer + Boolean"},
+        {"1.5..3", UnknownOperatorError + ": Float .. Integer"},
+        {"range(0, 5, 0)", InvalidArgumentError + ": range step cannot be zero"},
+        {`range("a")`, ArgumentTypesError + ": range(String)"},
+        {"collect(1)", ArgumentTypesError + ": collect(Integer)"},
+    }
+
+    for i, tst := range errorTests {
+        obj := runNewEval(t, tst.input)
+
+        res := assertCast[*object.Error](t, i, obj)
+        assert(t, i, res.Message, tst.expected)
+    }
+}
+
+func TestStringBuiltins(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {`split("a,b,c", ",")`, []string{"a", "b", "c"}},
+        {`split("abc", "")`, []string{"a", "b", "c"}},
+        {`split("", ",")`, []string{""}},
+        {`join(["a", "b", "c"], "-")`, "a-b-c"},
+        {`join([], "-")`, ""},
+        {`trim("  a b  ")`, "a b"},
+        {`trim_left("  a ")`, "a "},
+        {`trim_right("  a ")`, "  a"},
+        {`upper("Hello")`, "HELLO"},
+        {`lower("Hello")`, "hello"},
+        {`replace("a-b-c", "-", "+")`, "a+b+c"},
+        {`contains("hello", "ell")`, true},
+        {`contains("hello", "z")`, false},
+        {`contains([1, 2, 3], 2)`, true},
+        {`contains([[1], [2]], [2])`, true},
+        {`contains([1, 2, 3], "2")`, false},
+        {`starts_with("hello", "he")`, true},
+        {`starts_with("hello", "lo")`, false},
+        {`ends_with("hello", "lo")`, true},
+        {`index_of("hello", "l")`, 2},
+        {`index_of("hello", "z")`, -1},
+        {`index_of([1, 2, 3], 3)`, 2},
+        {`repeat("ab", 3)`, "ababab"},
+        {`repeat("ab", 0)`, ""},
+        {`pad_left("7", 3)`, "  7"},
+        {`pad_left("7", 3, "0")`, "007"},
+        {`pad_right("ab", 5, "xy")`, "abxyx"},
+        {`pad_right("abc", 2)`, "abc"},
+        {`chars("héllo")`, []string{"h", "é", "l", "l", "o"}},
+        {`chars("")`, []string{}},
+        {`join(map(split("a b", " "), upper), "")`, "AB"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case string:
+            res := assertCast[*object.String](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case []string:
+            arr := assertCast[*object.Array](t, i, obj)
+            assertMsg(t, i, len(arr.Elements), len(expd), "wrong number of elements")
+            for idx, el := range arr.Elements {
+                res := assertCast[*object.String](t, i, el)
+                assert(t, i, res.Value, expd[idx])
+            }
+        }
+    }
+
+    errorTests := []struct{
+        input    string
+        expected string
+    }{
+        {`split("a")`, ArgumentMistmatchError + ": split"},
+        {`split("a", 1)`, ArgumentTypesError + ": split(String, Integer)"},
+        {`join("a", "b")`, ArgumentTypesError + ": join(String, String)"},
+        {`join([1, 2], ",")`, ArgumentTypesError + ": join(Array[Integer], String)"},
+        {`trim(1)`, ArgumentTypesError + ": trim(Integer)"},
+        {`upper("a", "b")`, ArgumentMistmatchError + ": upper"},
+        {`replace("a", "b")`, ArgumentMistmatchError + ": replace"},
+        {`contains(1, 1)`, ArgumentTypesError + ": contains(Integer, Integer)"},
+        {`repeat("a", -1)`, InvalidArgumentError + ": repeat count -1"},
+        {`repeat("ab", 9223372036854775807)`, InvalidArgumentError + ": repeat count 9223372036854775807 exceeds the maximum string length"},
+        {`repeat("ab", 134217729)`, InvalidArgumentError + ": repeat count 134217729 exceeds the maximum string length"},
+        {`pad_left("a", 9223372036854775807)`, InvalidArgumentError + ": pad_left width 9223372036854775807 exceeds the maximum string length"},
+        {`pad_right("a", 268435457, "xy")`, InvalidArgumentError + ": pad_right width 268435457 exceeds the maximum string length"},
+        {`repeat(1, "a")`, ArgumentTypesError + ": repeat(Integer, String)"},
+        {`pad_left("a", "b")`, ArgumentTypesError + ": pad_left(String, String)"},
+        {`pad_right("a", 3, "")`, InvalidArgumentError + ": pad_right with empty padding"},
+        {`chars([])`, ArgumentTypesError + ": chars(Array)"},
+    }
+
+    for i, tst := range errorTests {
+        obj := runNewEval(t, tst.input)
+
+        res := assertCast[*object.Error](t, i, obj)
+        assert(t, i, res.Message, tst.expected)
+    }
+}
+
+func TestTypeBuiltins(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"type(1)", "Integer"},
+        {`type("a")`, "String"},
+        {"type([1])", "Array"},
+        {"type(null)", "Null"},
+        {"type(fn() {})", "Function"},
+        {"type(len)", "Builtin"},
+        {"str(12)", "12"},
+        {`str("a")`, "a"},
+        {"str([1, 2])", "[1, 2]"},
+        {"str(true)", "true"},
+        {"str(null)", "null"},
+        {`int("42")`, 42},
+        {`int("-7")`, -7},
+        {"int(5)", 5},
+        {"int(true)", 1},
+        {"int(2.9)", 2},
+        {"int(-2.9)", -2},
+        {"int(-9223372036854775808.0)", -9223372036854775808},
+        {"type(1.5)", "Float"},
+        {`bool("true")`, true},
+        {`bool("false")`, false},
+        {"bool(0)", false},
+        {"bool(3)", true},
+        {"bool(null)", false},
+        {`is_string("a")`, true},
+        {"is_string(1)", false},
+        {"is_int(1)", true},
+        {"is_bool(false)", true},
+        {"is_float(1.0)", true},
+        {"is_float(1)", false},
+        {"is_array([])", true},
+        {"is_array(1)", false},
+        {"is_fn(fn() {})", true},
+        {"is_fn(len)", true},
+        {"is_fn(1)", false},
+        {"is_null(null)", true},
+        {"is_null(first([]))", true},
+        {`reduce(map(split("1,2,3", ","), int), fn(a, b) { a + b })`, 6},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case string:
+            res := assertCast[*object.String](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        }
+    }
+
+    errorTests := []struct{
+        input    string
+        expected string
+    }{
+        {`int("abc")`, ConversionError + `: "abc" to Integer`},
+        {`int("1.5")`, ConversionError + `: "1.5" to Integer`},
+        {"int(0.0 / 0.0)", ConversionError + ": NaN to Integer"},
+        {"int(1.0 / 0.0)", ConversionError + ": +Inf to Integer"},
+        {"int(9223372036854775808.0)", ConversionError + ": 9.223372036854776e+18 to Integer"},
+        {`bool("yes")`, ConversionError + `: "yes" to Boolean`},
+        {"int([])", ArgumentTypesError + ": int(Array)"},
+        {"bool([])", ArgumentTypesError + ": bool(Array)"},
+        {"type(1, 2)", ArgumentMistmatchError + ": type"},
+        {"is_int()", ArgumentMistmatchError + ": is_int"},
+    }
+
+    for i, tst := range errorTests {
+        obj := runNewEval(t, tst.input)
+
+        res := assertCast[*object.Error](t, i, obj)
+        assert(t, i, res.Message, tst.expected)
+    }
+}
+
+func TestArrayValueSemantics(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected []int
+    }{
+        {"let a = push(push(push([], 1), 2), 3); let b = push(a, 4); let c = push(a, 5); b", []int{1, 2, 3, 4}},
+        {"let a = push(push(push([], 1), 2), 3); let b = push(a, 4); let c = push(a, 5); c", []int{1, 2, 3, 5}},
+        {"let a = push(push(push([], 1), 2), 3); let b = push(a, 4); a", []int{1, 2, 3}},
+        {"let a = push(push(push([], 1), 2), 3); let b = push(head(a), 9); let c = push(a, 4); b", []int{1, 2, 9}},
+        {"let a = push(push(push([], 1), 2), 3); let b = push(tail(a), 4); let c = push(a, 5); b", []int{2, 3, 4}},
+        {"let a = push(push(push([], 1), 2), 3); let b = push(tail(a), 4); let c = push(a, 5); c", []int{1, 2, 3, 5}},
+        {"let a = [1, 2]; let b = push(a, 3); let c = push(a, 4); b", []int{1, 2, 3}},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        arr := assertCast[*object.Array](t, i, obj)
+        assertMsg(t, i, len(arr.Elements), len(tst.expected), "wrong number of elements")
+        for idx, el := range arr.Elements {
+            res := assertCast[*object.Integer](t, i, el)
+            assert(t, i, res.Value, int64(tst.expected[idx]))
+        }
+    }
+}
+
+func TestDestructuring(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"let [a, b] = [1, 2]; a + b", 3},
+        {"let [a, ..rest] = [1, 2, 3]; rest", "[2, 3]"},
+        {"let [_, [x, y]] = [0, [4, 5]]; x * y", 20},
+        {"let [a, ..] = [7, 8]; a", 7},
+        {"let [] = []; 1", 1},
+        {"let [a, b] = [1, 2]", "[1, 2]"},
+        {"let swap = fn([a, b]) { [b, a] }; swap([1, 2])", "[2, 1]"},
+        {"let f = fn([h, ..t], n) { len(t) + h + n }; f([1, 2, 3], 10)", 13},
+        {"let add = fn([a, b], [c, d]) { [a + c, b + d] }; add([1, 2], [3, 4])", "[4, 6]"},
+        {"map([[1, 2], [3, 4]], fn([a, b]) { a * b })", "[2, 12]"},
+        {"let [a, b] = [1, 2]; let [b, a] = [a, b]; [a, b]", "[2, 1]"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestFunctionParameters(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"let f = fn(x, y = 10) { x + y }; f(1)", 11},
+        {"let f = fn(x, y = 10) { x + y }; f(1, 2)", 3},
+        {"let f = fn(x, y = x * 2) { y }; f(4)", 8},
+        {"let n = 5; let f = fn(x = n) { x }; f()", 5},
+        {"let f = fn(first, ...rest) { rest }; f(1, 2, 3)", "[2, 3]"},
+        {"let f = fn(first, ...rest) { rest }; f(1)", "[]"},
+        {"let f = fn(...args) { len(args) }; f()", 0},
+        {"let add = fn(a, b) { a + b }; add(...[1, 2])", 3},
+        {"let add = fn(a, b, c) { a + b + c }; add(1, ...[2, 3])", 6},
+        {"let f = fn(...xs) { xs }; f(...1..4, 9)", "[1, 2, 3, 9]"},
+        {"math.max(...[3, 7, 2])", 7},
+        {"push(...[[1], 2])", "[1, 2]"},
+        {"let sub = fn(x, y) { x - y }; sub(y: 2, x: 10)", 8},
+        {"let sub = fn(x, y) { x - y }; sub(10, y: 3)", 7},
+        {"let f = fn(x, sep = \",\", end = \"!\") { x + sep + end }; f(\"a\", end: \"?\")", "a,?"},
+        {"let f = fn(a, b = 2, ...rest) { [a, b, rest] }; f(1, 2, 3, 4)", "[1, 2, [3, 4]]"},
+        {"let f = fn([a, b] = [1, 2]) { a + b }; f()", 3},
+        {"let scale = fn(xs, by = 2) { map(xs, fn(x) { x * by }) }; [1, 2].scale(by: 3)", "[3, 6]"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
 func TestFunctionExpression(t *testing.T) {
     tests := []struct{
         input      string
@@ -126,7 +561,7 @@ func TestFunctionExpression(t *testing.T) {
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
         f := assertCast[*object.Function](t, i, obj)
 
         assertMsg(t, i, len(f.Parameters), tst.expdParams, "wrong nunmber of parameters in function object")
@@ -148,7 +583,7 @@ func TestCallExpression(t *testing.T) {
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         res := assertCast[*object.Integer](t, i, obj)
         assert(t, i, res.Value, tst.expected)
@@ -169,7 +604,7 @@ func TestConditionalExpression(t *testing.T) {
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         expd, ok := tst.expected.(int)
         if !ok {
@@ -185,7 +620,7 @@ func TestConditionalExpression(t *testing.T) {
 func TestArrayLiteral(t *testing.T) {
     input := "[1, 2 * 3, fn(){ 5 * 6 }()]"
 
-    obj := runNewEval(input)
+    obj := runNewEval(t, input)
     arr := assertCast[*object.Array](t, 0, obj)
 
     first := assertCast[*object.Integer](t, 0, arr.Elements[0])
@@ -207,10 +642,13 @@ func TestIndexExpression(t *testing.T) {
         {`"hello"[0]`, "h"},
         {`"world"[1]`, "o"},
         {`let s = "asdf"; s[2]`, "d"},
+        {"[1, 2, 3][-1]", 3},
+        {"[1, 2, 3][-3]", 1},
+        {`"hello"[-1]`, "o"},
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         switch expd := tst.expected.(type) {
         case int:
@@ -223,6 +661,55 @@ func TestIndexExpression(t *testing.T) {
     }
 }
 
+func TestSliceExpression(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"[1, 2, 3, 4][1:3]", []int{2, 3}},
+        {"[1, 2, 3, 4][:2]", []int{1, 2}},
+        {"[1, 2, 3, 4][2:]", []int{3, 4}},
+        {"[1, 2, 3, 4][:]", []int{1, 2, 3, 4}},
+        {"[1, 2, 3, 4][-2:]", []int{3, 4}},
+        {"[1, 2, 3, 4][:-1]", []int{1, 2, 3}},
+        {"[1, 2, 3, 4][::2]", []int{1, 3}},
+        {"[1, 2, 3, 4][1::2]", []int{2, 4}},
+        {"[1, 2, 3, 4][::-1]", []int{4, 3, 2, 1}},
+        {"[1, 2, 3, 4][2:0:-1]", []int{3, 2}},
+        {"[1, 2, 3, 4][10:]", []int{}},
+        {"[1, 2, 3, 4][-10:10]", []int{1, 2, 3, 4}},
+        {"[1, 2, 3, 4][3:1]", []int{}},
+        {`"hello"[1:3]`, "el"},
+        {`"hello"[:-1]`, "hell"},
+        {`"hello"[::-1]`, "olleh"},
+        {`"hello"[10:]`, ""},
+        {"[1, 2, 3][2::9223372036854775807]", []int{3}},
+        {"[1, 2, 3][::9223372036854775807]", []int{1}},
+        {"[1, 2, 3][::-9223372036854775807 - 1]", []int{3}},
+        {"[1, 2, 3][0::-9223372036854775807 - 1]", []int{1}},
+        {"[1, 2, 3][-9223372036854775807 - 1:9223372036854775807]", []int{1, 2, 3}},
+        {"[1, 2, 3][9223372036854775807:-9223372036854775807 - 1:-1]", []int{3, 2, 1}},
+        {`"abc"[::-9223372036854775807]`, "c"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case []int:
+            arr := assertCast[*object.Array](t, i, obj)
+            assertMsg(t, i, len(arr.Elements), len(expd), "wrong number of elements")
+            for idx, el := range arr.Elements {
+                res := assertCast[*object.Integer](t, i, el)
+                assert(t, i, res.Value, int64(expd[idx]))
+            }
+        case string:
+            res := assertCast[*object.String](t, i, obj)
+            assert(t, i, res.Value, expd)
+        }
+    }
+}
+
 func TestStringLiteral(t *testing.T) {
     tests := []struct {
         input    string
@@ -234,7 +721,7 @@ func TestStringLiteral(t *testing.T) {
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         res := assertCast[*object.String](t, i, obj)
         assert(t, i, res.Value, tst.expected)
@@ -263,13 +750,493 @@ func TestIntegerExpression(t *testing.T) {
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         res := assertCast[*object.Integer](t, i, obj)
         assert(t, i, res.Value, tst.expected)
     }
 }
 
+func TestFloatExpression(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected float64
+    }{
+        {"1.5", 1.5},
+        {"-2.25", -2.25},
+        {"1.5 + 1.5", 3},
+        {"1.5 * 2", 3},
+        {"1 + 0.5", 1.5},
+        {"7 / 2.0", 3.5},
+        {"10.0 - 2.5 * 2", 5},
+        {`float("2.5")`, 2.5},
+        {"float(3)", 3},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        res := assertCast[*object.Float](t, i, obj)
+        assert(t, i, res.Value, tst.expected)
+    }
+}
+
+func TestMathNamespace(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"math.abs(-3)", 3},
+        {"math.abs(3)", 3},
+        {"math.abs(-1.5)", 1.5},
+        {"math.min(3, 1, 2)", 1},
+        {"math.max(3, 1, 2)", 3},
+        {"math.max(1, 2.5)", 2.5},
+        {"math.pow(2, 10)", 1024},
+        {"math.pow(4, 0.5)", 2.0},
+        {"math.pow(2, -1)", 0.5},
+        {"math.pow(1, 9223372036854775807)", 1},
+        {"math.pow(-1, 9223372036854775807)", -1},
+        {"math.pow(0, 9223372036854775807)", 0},
+        {"math.pow(2, 62)", 4611686018427387904},
+        {"math.pow(-2, 63)", -9223372036854775808},
+        {"math.pow(3, 0)", 1},
+        {"math.sqrt(16)", 4.0},
+        {"math.floor(1.7)", 1},
+        {"math.floor(-1.2)", -2},
+        {"math.ceil(1.2)", 2},
+        {"math.round(2.5)", 3},
+        {"math.round(4)", 4},
+        {"math.clamp(5, 0, 3)", 3},
+        {"math.clamp(-1, 0, 3)", 0},
+        {"math.clamp(1.5, 0, 3)", 1.5},
+        {"math.gcd(12, 18)", 6},
+        {"math.gcd(-4, 6)", 2},
+        {"math.PI > 3.14 && math.PI < 3.15", true},
+        {"math.E > 2.71", true},
+        {"let abs = math.abs; abs(-2)", 2},
+        {"let math = 5; math", 5},
+        {"map([-1, 2], math.abs)", "[1, 2]"},
+        {"str(2.0)", "2.0"},
+        {"str(1.0 / 4)", "0.25"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case float64:
+            res := assertCast[*object.Float](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestMethodCall(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"[1, 2, 3].len()", 3},
+        {"[1, 2, 3].push(4)", "[1, 2, 3, 4]"},
+        {"let a = [1, 2]; a.push(3) == push(a, 3)", true},
+        {`"a,b".split(",").join("-")`, "a-b"},
+        {`"  hi ".trim().upper()`, "HI"},
+        {"[3, 1, 2].sort().first()", 1},
+        {"(1..=5).map(fn(x) { x * x }).filter(fn(x) { x > 4 }).collect()", "[9, 16, 25]"},
+        {"[1, 2, 3].reduce(fn(a, b) { a + b }, 10)", 16},
+        {"2.5.str()", "2.5"},
+        {"let double = fn(x) { x * 2 }; 4.double()", 8},
+        {"let add = fn(a, b) { a + b }; 1.add(2).add(3)", 6},
+        {"math.max(1, 5)", 5},
+        {"let len = fn(x) { 0 }; [1].len()", 1},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestPipeline(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"[3, 1, 2] |> sort |> first", 1},
+        {"1..=5 |> map(fn(x) { x * 2 }) |> filter(fn(x) { x > 4 }) |> collect", "[6, 8, 10]"},
+        {`"a b" |> split(" ") |> join(",")`, "a,b"},
+        {"let inc = fn(x, n) { x + n }; 1 |> inc(2) |> inc(3)", 6},
+        {"-4 |> math.abs", 4},
+        {"[1, 2] |> fn(a) { a.len() }", 2},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestMatchExpression(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"match 2 { 1 => 10, 2 => 20, _ => 0 }", 20},
+        {"match 5 { 1 => 10, _ => 0 }", 0},
+        {"match 5 { n => n * 2 }", 10},
+        {`match "b" { "a" => 1, "b" => 2 }`, 2},
+        {"match null { null => true, _ => false }", true},
+        {"match -1 { -1 => true, _ => false }", true},
+        {"match 2.0 { 2 => true, _ => false }", true},
+        {"match [] { [] => 0, _ => 1 }", 0},
+        {"match [1, 2] { [a] => a, [a, b] => a + b }", 3},
+        {"match [1, 2, 3] { [first, ..rest] => rest }", "[2, 3]"},
+        {"match [1] { [first, ..rest] => rest }", "[]"},
+        {"match [1, 2, 3] { [_, ..] => true }", true},
+        {"match [1, [2, 3]] { [_, [x, y]] => x * y }", 6},
+        {"match [1, 2] { [1, x] => x, _ => 0 }", 2},
+        {"match [3, 2] { [1, x] => x, _ => 0 }", 0},
+        {"match 5 { x if x > 10 => 1, x if x > 3 => 2, _ => 3 }", 2},
+        {"match 5 { x => { let y = x + 1; y * 2 } }", 12},
+        {"let x = 1; match 5 { x => x }; x", 1},
+        {"match 1 { [a] => a, _ => 2 }", 2},
+        {`
+            let sum = fn(xs) {
+                match xs {
+                    [] => 0,
+                    [x, ..rest] => x + sum(rest),
+                }
+            };
+            sum([1, 2, 3, 4])
+        `, 10},
+        {"let f = fn(x) { match x { 1 => { return 10 } }; 20 }; f(1)", 10},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestTryExpression(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"try { 1 } catch { 2 }", 1},
+        {"try { 1 + true } catch { 2 }", 2},
+        {`try { throw "bad" } catch e { e.message }`, "bad"},
+        {`try { throw "bad" } catch e { e.kind }`, ThrownErrorKind},
+        {"try { throw [1, 2] } catch e { e.value }", "[1, 2]"},
+        {"try { 1 + true } catch e { e.message }", TypeMismatchError + ": Integer + Boolean"},
+        {"try { 1 + true } catch e { e.kind }", TypeMismatchError},
+        {"try { 1 + true } catch e { e.value }", "null"},
+        {"try { x } catch e { [e.line, e.col] }", "[1, 7]"},
+        {"try {\n  let a = 1;\n  a + [] } catch e { [e.line, e.col] }", "[3, 5]"},
+        {"try { x } catch e { type(e) }", "Exception"},
+        {"try { try { x } catch e { throw e } } catch e { e.kind }", IdentifierNotFoundError},
+        {"try { try { x } catch e { throw 5 } } catch e { e.value }", 5},
+        {"let n = 0; try { n } finally { 10 }", 0},
+        {"let log = fn(x) { x }; try { x } catch { 1 } finally { log(2) }", 1},
+        {"let f = fn() { try { return 1 } finally { 2 }; 3 }; f()", 1},
+        {"let f = fn() { try { 1 } finally { return 2 } }; f()", 2},
+        {`
+            let parse = fn(s) { if s == "" { throw "empty" }; len(s) };
+            map(["ab", "", "c"], fn(s) { try { parse(s) } catch e { e.message } })
+        `, "[2, empty, 1]"},
+        {"let e = try { x } catch e { e }; e == e", true},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestErrorPosition(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        {"1 + true", "Error: type mismatch: Integer + Boolean (line 1, col 3)"},
+        {"let a = 1;\nlet b = a + x;", "Error: identifier not found: x (line 2, col 13)"},
+        {`throw "oops"`, "Error: oops (line 1, col 1)"},
+        {"let f = fn() { [1][5] };\n\nf()", "Error: index out of bounds: 5 (line 1, col 19)"},
+        {"try { x } finally { y }", "Error: identifier not found: y (line 1, col 21)"},
+        {"let e = try { 1 + true } catch e { e };\nthrow e", "Error: type mismatch: Integer + Boolean (line 1, col 17)"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        assertCast[*object.Error](t, i, obj)
+        assert(t, i, obj.String(), tst.expected)
+    }
+}
+
+func TestRecords(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"struct Point { x, y }; Point(1, 2)", "Point {x: 1, y: 2}"},
+        {"struct Point { x, y }; Point(y: 2, x: 1)", "Point {x: 1, y: 2}"},
+        {"struct Point { x, y }; Point(1, y: 2).y", 2},
+        {"struct Point { x, y }; let p = Point(1, 2); p.x + p.y", 3},
+        {"struct Point { x, y }; type(Point(1, 2))", "Point"},
+        {"struct Point { x, y }; type(Point)", "Struct"},
+        {"struct Point { x, y }; Point", "struct Point"},
+        {"struct Point { x, y }; let p = Point(1, 2); p with { x: 3 }", "Point {x: 3, y: 2}"},
+        {"struct Point { x, y }; let p = Point(1, 2); let q = p with { x: 3 }; p.x", 1},
+        {"struct Point { x, y }; Point(1, 2) == Point(1, 2)", true},
+        {"struct Point { x, y }; Point(1, 2) == Point(1, 3)", false},
+        {"struct Point { x, y }; Point(1, [2]) != Point(1, [2])", false},
+        {"struct A { v }; struct B { v }; A(1) == B(1)", false},
+        {"struct Point { x, y }; Point(1, 2) == Point(1, 2) with { y: 2 }", true},
+        {"struct Empty {}; Empty()", "Empty {}"},
+        {"struct P { x }; map([1, 2], P)", "[P {x: 1}, P {x: 2}]"},
+        {"struct P { x }; [1, 2] |> map(P) |> map(fn(p) { p.x * 10 })", "[10, 20]"},
+        {"struct P { x }; let getx = fn(p) { p.x }; P(4).getx()", 4},
+        {"struct Box { f }; Box(fn(n) { n + 1 }).f(1)", 2},
+        {"struct P { x }; match P(1).x { 1 => true, _ => false }", true},
+        {"struct P { x }; [P(2), P(1)] |> sort_by(fn(p) { p.x }) |> first", "P {x: 1}"},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestEnums(t *testing.T) {
+    shape := "enum Shape { Circle(r), Rect(w, h), Empty }; "
+    area := shape + `let area = fn(s) {
+        match s {
+            Shape.Circle(r) => r * r * 3,
+            Shape.Rect(w, h) if w == h => w * w,
+            Shape.Rect(w, h) => w * h,
+            Shape.Empty => 0,
+        }
+    }; `
+
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {shape + "Shape.Circle(2)", "Circle(2)"},
+        {shape + "Shape.Rect(h: 2, w: 1)", "Rect(1, 2)"},
+        {shape + "Shape.Empty", "Empty"},
+        {shape + "Shape.Circle(2).r", 2},
+        {shape + "type(Shape.Circle(2))", "Shape"},
+        {shape + "type(Shape)", "Enum"},
+        {shape + "Shape.Circle", "variant Shape.Circle"},
+        {shape + "Shape.Empty == Shape.Empty", true},
+        {shape + "Shape.Circle(1) == Shape.Circle(1)", true},
+        {shape + "Shape.Circle(1) == Shape.Rect(1, 1)", false},
+        {shape + "[1, 2] |> map(Shape.Circle) |> len", 2},
+        {area + "area(Shape.Circle(2))", 12},
+        {area + "area(Shape.Rect(3, 3))", 9},
+        {area + "area(Shape.Rect(2, 5))", 10},
+        {area + "area(Shape.Empty)", 0},
+        {shape + "match Shape.Rect(1, 2) { Shape.Rect => 1, _ => 2 }", 1},
+        {shape + "match Shape.Rect([1, 2], 3) { Shape.Rect([a, b], c) => a + b + c }", 6},
+        {shape + "let Circle = Shape.Circle; match Circle(5) { Circle(r) => r }", 5},
+        {"let f = fn(o) { match o { Some(x) => x, None => 0 } }; f(Some(3)) + f(None)", 3},
+        {"match Err(\"bad\") { Ok(v) => v, Err(e) => e }", "bad"},
+        {"Some(1) == Option.Some(1)", true},
+        {"Some(1) == Ok(1)", false},
+        {"type(None)", "Option"},
+        {"is_variant(Ok(1), Ok)", true},
+        {"is_variant(Ok(1), Err)", false},
+        {"is_variant(None, None)", true},
+        {"is_variant(1, Some)", false},
+        {"let Some = 1; Some", 1},
+        // capitalised names which refer to nothing bind like any other name
+        {"let [X, y] = [1, 2]; X + y", 3},
+        {"match 5 { N => N }", 5},
+        {"let f = fn(o) { match o { Some(X) => X, Other => 0 } }; f(Some(3)) + f(None)", 3},
+        {"let N = 5; match 5 { N => 1, _ => 2 }", 1},
+        {"let N = 4; match 5 { N => 1, _ => 2 }", 2},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+func TestConstants(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"const x = 5; x * 2", 10},
+        {"const x = 5; let f = fn() { let x = 1; x }; f() + x", 6},
+        {"const x = 5; if (true) { const x = 2; x }", 2},
+        {"let x = 1; const x = 2; x", 2},
+        {"const [a, ..rest] = [1, 2, 3]; a + len(rest)", 3},
+        {"const xs = [1, [2]]; is_frozen(xs)", true},
+        {"const xs = [1, [2]]; is_frozen(xs[1])", true},
+        {"const [a, b] = [[1], [2]]; is_frozen(b)", true},
+        {"const xs = [1, 2]; is_frozen(tail(xs))", true},
+        {"const [a, ..rest] = [1, 2, 3]; is_frozen(rest)", true},
+        {"const xs = [1, 2]; is_frozen(xs[1:])", true},
+        {"const xs = [1, 2, 3]; is_frozen(xs[::-1])", true},
+        {"let a = [1]; const b = a; [is_frozen(a), is_frozen(b)]", "[false, true]"},
+        {"let a = [[1]]; const b = a; is_frozen(a[0])", false},
+        {"let a = [1]; const [x] = [a]; [is_frozen(a), is_frozen(x)]", "[false, true]"},
+        {"let a = [1]; const b = a; push(a, 2); a", "[1]"},
+        {"let a = [1]; const b = a; a == b", true},
+        {"struct Cfg { hosts }; let h = [\"a\"]; const c = Cfg(h); [is_frozen(h), is_frozen(c.hosts)]", "[false, true]"},
+        {"const xs = [1, 2]; is_frozen(push(xs, 3))", false},
+        {"const xs = [1, 2]; let ys = push(xs, 3); let zs = push(xs, 4); [xs, ys, zs]", "[[1, 2], [1, 2, 3], [1, 2, 4]]"},
+        {"struct Cfg { hosts }; const c = Cfg([\"a\"]); is_frozen(c.hosts)", true},
+        {"let xs = [1]; is_frozen(xs)", false},
+        {"is_frozen(1)", false},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            assert(t, i, obj.String(), expd)
+        }
+    }
+}
+
+// constants are also enforced at runtime when a scope outlives a single program (as in the REPL)
+func TestConstReassignment(t *testing.T) {
+    tests := []string{
+        "let x = 2",
+        "const x = 2",
+        "let [a, x] = [1, 2]",
+        "struct x { a }",
+        "enum x { A }",
+    }
+
+    for i, input := range tests {
+        env := object.CreateEnvironment()
+        Eval(parser.New(lexer.New("const x = 1")).ParseProgram(), env)
+
+        obj := Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+        res := assertCast[*object.Error](t, i, obj)
+        assert(t, i, res.Message, ConstReassignmentError + ": x")
+
+        val, _ := env.Get("x")
+        assert(t, i, val.String(), "1")
+    }
+}
+
+// locals are read from their resolved slots, these must behave exactly like lookups by name
+func TestLocalSlots(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected int
+    }{
+        {"let x = 1; let f = fn(x) { if (true) { let x = x + 1; x } }; f(10) + x", 12},
+        {"let f = fn() { let x = 1; let g = fn() { x }; let x = 2; g() }; f()", 2},
+        {"let y = 1; let f = fn() { let g = fn() { y }; let a = g(); let y = 5; a + g() }; f()", 6},
+        {"let mk = fn() { let n = 1; fn(m) { n + m } }; mk()(2)", 3},
+        {"let f = fn() { let fib = fn(n) { if (n < 2) { return n } fib(n - 1) + fib(n - 2) }; fib(15) }; f()", 610},
+        {"let f = fn(v) { match v { [a, ..rest] if a > 0 => a + len(rest), x => try { throw x } catch e { e.value } } }; f([1, 2]) + f(5)", 7},
+        {"let f = fn(len) { len([1, 2]) }; f(0)", 2},
+        {"let f = fn() { let double = fn(n) { n * 2 }; 3.double() }; f()", 6},
+        {"let f = fn() { struct P { x }; P(4).x }; f()", 4},
+        {"let f = fn(a, b = a * 2) { a + b }; f(1) + f(1, b: 1)", 5},
+        {"let f = fn(...xs) { let [a, ..rest] = xs; a + len(rest) }; f(1, 2, 3)", 3},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+        res := assertCast[*object.Integer](t, i, obj)
+        assert(t, i, res.Value, int64(tst.expected))
+    }
+
+    // globals stay in the shared environment (as in the REPL)
+    env := object.CreateEnvironment()
+    Eval(parser.New(lexer.New("let f = fn(n) { n + x }")).ParseProgram(), env)
+
+    obj := Eval(parser.New(lexer.New("let x = 2; f(1)")).ParseProgram(), env)
+    res := assertCast[*object.Integer](t, 0, obj)
+    assert(t, 0, res.Value, int64(3))
+}
+
 func TestBooleanExpression(t *testing.T) {
     tests := []struct{
         input    string
@@ -298,6 +1265,10 @@ func TestBooleanExpression(t *testing.T) {
         {`"foo" == "bar"`, false},
         {`"foo" != "foo"`, false},
         {`"foo" != "bar"`, true},
+        {"1.5 < 2", true},
+        {"2.0 == 2", true},
+        {"2.5 > 2.5", false},
+        {"[1, 2.0] == [1.0, 2]", true},
         {"(1 < 2) == true", true},
         {"(1 < 2) == false", false},
         {"(1 > 2) == true", false},
@@ -310,16 +1281,93 @@ func TestBooleanExpression(t *testing.T) {
         {"true || false", true},
         {"false || true", true},
         {"false || false", false},
+        {`"a" < "b"`, true},
+        {`"b" > "ab"`, true},
+        {"[1, 2] == [1, 2]", true},
+        {"[1, 2] == [1, 3]", false},
+        {"[1, 2] != [1]", true},
+        {"[[1], [2, 3]] == [[1], [2, 3]]", true},
+        {"[] == []", true},
+        {"[1, 2] < [1, 3]", true},
+        {"[1, 2] < [1, 2, 0]", true},
+        {"[2] > [1, 5]", true},
+        {"[1, 2] < [1, 2]", false},
+        {"first([]) == first([])", true},
+        {"first([]) != first([])", false},
+        {"first([]) == 1", false},
+        {"[1] != first([])", true},
+        {`first([]) == ""`, false},
+        {"let f = fn(x) { x }; f == f", true},
+        {"fn(x) { x } == fn(x) { x }", false},
+        {"len == len", true},
+        {"len != first", true},
+        {"upper != lower", true},
+        {"upper == upper", true},
+        {"is_int == is_string", false},
+        {"let f = math.floor; f == math.floor && f != math.ceil", true},
+        {"[upper] == [lower]", false},
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         res := assertCast[*object.Boolean](t, i, obj)
         assert(t, i, res.Value, tst.expected)
     }
 }
 
+func TestNullExpression(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {"null", nil},
+        {"let a = null; a", nil},
+        {"null == null", true},
+        {"null != null", false},
+        {"first([]) == null", true},
+        {"if false { 1 } == null", true},
+        {"1 == null", false},
+        {`"" != null`, true},
+        {"[] == null", false},
+        {"null == fn() {}", false},
+        {"null ?? 1", 1},
+        {"2 ?? 1", 2},
+        {"first([]) ?? last([3])", 3},
+        {"null ?? null", nil},
+        {"1 ?? x", 1},
+        {"null?[0]", nil},
+        {"null?[1:]", nil},
+        {"[1, 2]?[1]", 2},
+        {"let a = null; a?[0] ?? 5", 5},
+        {"first([[1]])?[0]", 1},
+        {"let a = null; a?[0][1]", nil},
+        {"let a = null; a?[0][1:].x", nil},
+        {"let a = null; a?[0](1)", nil},
+        {"let a = null; a?[0].len()", nil},
+        {"let a = null; a?[x]", nil},
+        {"let a = null; a?[0][1] ?? 5", 5},
+        {"let a = [[1, 2]]; a?[0][1]", 2},
+        {"let a = [null]; a?[0]?[1]", nil},
+        {"let a = null; [a?[0][1]][0]", nil},
+    }
+
+    for i, tst := range tests {
+        obj := runNewEval(t, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case nil:
+            assert(t, i, obj, Null)
+        }
+    }
+}
+
 func TestErrorCases(t *testing.T) {
     tests := []struct{
         input    string
@@ -351,37 +1399,135 @@ func TestErrorCases(t *testing.T) {
 
         {"if 1 + 1 { 2 }", InvalidConditionError + ": (1 + 1)"},
 
+        {"1.5 + true", TypeMismatchError + ": Float + Boolean"},
+        {"1.5 && 2.5", UnknownOperatorError + ": Float && Float"},
+        {"math.foo", MemberNotFoundError + ": math.foo"},
+        {"1.foo", InvalidMemberAccessError + ": Integer.foo"},
+        {"math.sqrt(true)", ArgumentTypesError + ": math.sqrt(Boolean)"},
+        {"math.gcd(1.5, 2)", ArgumentTypesError + ": math.gcd(Float, Integer)"},
+        {"math.min()", ArgumentMistmatchError + ": math.min"},
+        {"math.clamp(1, 3, 0)", InvalidArgumentError + ": math.clamp bounds 3 > 0"},
+        {"math.pow(2, 64)", IntegerOverflowError + ": math.pow(2, 64)"},
+        {"math.abs(-9223372036854775807 - 1)", IntegerOverflowError + ": math.abs(-9223372036854775808)"},
+        {"math.gcd(-9223372036854775807 - 1, 0)", IntegerOverflowError + ": math.gcd(-9223372036854775808, 0)"},
+        {"math.floor(0.0 / 0.0)", ConversionError + ": math.floor(NaN) to Integer"},
+        {"math.round(-1.0 / 0.0)", ConversionError + ": math.round(-Inf) to Integer"},
+        {"math.ceil(10000000000000000000.0)", ConversionError + ": math.ceil(1e+19) to Integer"},
+        {"math.pow(2, 63)", IntegerOverflowError + ": math.pow(2, 63)"},
+        {"math.pow(-3, 9223372036854775807)", IntegerOverflowError + ": math.pow(-3, 9223372036854775807)"},
+        {"abs(1)", IdentifierNotFoundError + ": abs"},
+        {"[1].foo()", MethodNotFoundError + ": Array.foo"},
+        {"let x = 1; 2.x()", MethodNotFoundError + ": Integer.x"},
+        {"[1].len", InvalidMemberAccessError + ": Array.len"},
+        {"math.foo(1)", MemberNotFoundError + ": math.foo"},
+        {"x.len()", IdentifierNotFoundError + ": x"},
+        {"true.len()", ArgumentTypesError + ": len(Boolean)"},
+
+        {"[1] + [2]", UnknownOperatorError + ": Array + Array"},
+        {"[1] == 1", TypeMismatchError + ": Array == Integer"},
+        {"first([]) < 1", UnknownOperatorError + ": Null < Integer"},
+        {"null + 1", UnknownOperatorError + ": Null + Integer"},
+        {"null[0]", InvalidIndexExpressionError + ": cannot index Null with Integer"},
+        {"let a = [null]; a?[0][1]", InvalidIndexExpressionError + ": cannot index Null with Integer"},
+        {"null ?? x", IdentifierNotFoundError + ": x"},
+        {"len < len", UnknownOperatorError + ": Builtin < Builtin"},
+        {"[len] < [len]", NotOrderedError + ": [builtin function] < [builtin function]"},
+
         {"x", IdentifierNotFoundError + ": x"},
         {"!x", IdentifierNotFoundError + ": x"},
         {"if x { y }", IdentifierNotFoundError + ": x"},
         {"return x", IdentifierNotFoundError + ": x"},
 
-        {"[1, 2][-1]", IndexOutOfBoundsError + ": -1"},
+        {"[1, 2][-3]", IndexOutOfBoundsError + ": -3"},
         {"[1, 2][2]", IndexOutOfBoundsError + ": 2"},
-        {`"hello"[-1]`, IndexOutOfBoundsError + ": -1"},
+        {`"hello"[-6]`, IndexOutOfBoundsError + ": -6"},
         {`"world"[5]`, IndexOutOfBoundsError + ": 5"},
 
         {"[1, 2][true]", InvalidIndexExpressionError + ": cannot index Array with Boolean"},
         {`[1, 2]["asdf"]`, InvalidIndexExpressionError + ": cannot index Array with String"},
         {`""[true]`, InvalidIndexExpressionError + ": cannot index String with Boolean"},
         {`""["asdf"]`, InvalidIndexExpressionError + ": cannot index String with String"},
+        {"[1, 2][true:]", InvalidIndexExpressionError + ": cannot slice Array with Boolean"},
+        {"1[1:]", InvalidIndexExpressionError + ": cannot slice Integer"},
+        {"[1, 2][::0]", InvalidSliceError + ": step cannot be zero"},
+
+        {"match 3 { 1 => 1, 2 => 2 }", NoMatchError + ": 3"},
+        {"match [1, 2] { [a] => a }", NoMatchError + ": [1, 2]"},
+        {"match 1 { x if x => 1 }", InvalidConditionError + ": x"},
+        {"match y { _ => 1 }", IdentifierNotFoundError + ": y"},
+        {"match [1] { [x] => 1 }; x", IdentifierNotFoundError + ": x"},
+
+        {"let f = fn(x) { x }; f()", ArgumentMistmatchError + ": f expects 1, got 0"},
+        {"let f = fn(x) { x }; f(1, 2)", ArgumentMistmatchError + ": f expects 1, got 2"},
+        {"fn(x, y = 1) { x }(1, 2, 3)", ArgumentMistmatchError + ": fn expects 1 to 2, got 3"},
+        {"let f = fn(x, ...r) { x }; f()", ArgumentMistmatchError + ": f expects at least 1, got 0"},
+        {"let f = fn(x) { x }; let g = f; g()", ArgumentMistmatchError + ": f expects 1, got 0"},
+        {"let f = fn(x) { x }; f(y: 1)", InvalidArgumentError + ": f has no parameter y"},
+        {"let f = fn(x) { x }; f(1, x: 2)", InvalidArgumentError + ": f got more than one value for x"},
+        {"let f = fn(x) { x }; f(x: 1, x: 2)", InvalidArgumentError + ": x given more than once"},
+        {"len(x: [])", InvalidArgumentError + ": Builtin does not accept named arguments"},
+        {"[1, 2].push(x: 3)", InvalidArgumentError + ": Builtin does not accept named arguments"},
+        {"let f = fn(x) { x }; f(...1)", InvalidArgumentError + ": cannot spread Integer"},
+        {"let f = fn(x = y) { x }; f()", IdentifierNotFoundError + ": y"},
+
+        {`throw "bad"`, "bad"},
+        {"try { x } catch e { e.foo }", MemberNotFoundError + ": Exception.foo"},
+
+        {"struct P { x, y }; P(1)", ArgumentMistmatchError + ": P expects 2, got 1"},
+        {"struct P { x, y }; P(1, 2, 3)", ArgumentMistmatchError + ": P expects 2, got 3"},
+        {"struct P { x, y }; P(1, z: 2)", InvalidArgumentError + ": P has no field z"},
+        {"struct P { x, y }; P(1, x: 2)", InvalidArgumentError + ": P got more than one value for x"},
+        {"struct P { x }; P(1).y", MemberNotFoundError + ": P.y"},
+        {"struct P { x }; P(1) with { y: 2 }", MemberNotFoundError + ": P.y"},
+        {"1 with { x: 2 }", InvalidUpdateError + ": Integer with {...}"},
+        {"enum E { A(x) }; E.B", MemberNotFoundError + ": E.B"},
+        {"enum E { A(x) }; E.A(1, 2)", ArgumentMistmatchError + ": A expects 1, got 2"},
+        {"enum E { A(x) }; match E.A(1) { E.A(a, b) => a }", InvalidPatternError + ": E.A has 1 fields, got 2"},
+        {"match 1 { None(x) => x }", InvalidPatternError + ": None is not a constructor"},
+        {"match None { Some(x) => x }", NoMatchError + ": None"},
+        {"struct P { x }; P(1) < P(2)", UnknownOperatorError + ": Record < Record"},
+
+        {"let [a, b] = [1]", PatternMismatchError + ": [a, b] = [1]"},
+        {"let [a] = [1, 2]", PatternMismatchError + ": [a] = [1, 2]"},
+        {"let [a, ..rest] = []", PatternMismatchError + ": [a, ..rest] = []"},
+        {"let [a] = 1", PatternMismatchError + ": [a] = 1"},
+        {"let f = fn([a, b]) { a }; f([1, 2, 3])", PatternMismatchError + ": [a, b] = [1, 2, 3]"},
+        {"let f = fn([a]) { a }; f(5)", PatternMismatchError + ": [a] = 5"},
     }
 
     for i, tst := range tests {
-        obj := runNewEval(tst.input)
+        obj := runNewEval(t, tst.input)
 
         res := assertCast[*object.Error](t, i, obj)
         assert(t, i, res.Message, tst.expected)
     }
 }
 
-func runNewEval(input string) object.Object {
+// CompareRun is set by tests of other implementations of Eval (e.g. the vm), every
+// program run by the tests below is then also run by it and the results compared
+var CompareRun func(program ast.Program, env *object.Environment) object.Object
+
+func runNewEval(t *testing.T, input string) object.Object {
+    t.Helper()
+
     l := lexer.New(input)
     p := parser.New(l)
     program := p.ParseProgram()
     env := object.CreateEnvironment()
 
-    return Eval(program, env)
+    obj := Eval(program, env)
+    compareRun(t, input, obj, object.CreateEnvironment)
+    return obj
+}
+
+func compareRun(t *testing.T, input string, expected object.Object, newEnv func() *object.Environment) {
+    t.Helper()
+    if CompareRun == nil { return }
+
+    obj := CompareRun(parser.New(lexer.New(input)).ParseProgram(), newEnv())
+    if obj.String() != expected.String() || obj.Type() != expected.Type() {
+        t.Errorf("%q: eval gives %s %q, compared run gives %s %q", input, expected.Type(), expected, obj.Type(), obj)
+    }
 }
 
 func assert(t *testing.T, testIdx int, val, expected any) {
@@ -412,3 +1558,34 @@ func assertCast[T object.Object](t *testing.T, testIdx int, obj object.Object) T
 
     return o
 }
+
+func BenchmarkFibonacci(b *testing.B) {
+    benchmarkLookups(b, "let fib = fn(n) { if (n < 2) { return n } fib(n - 1) + fib(n - 2) }; fib(20)")
+}
+
+func BenchmarkClosures(b *testing.B) {
+    benchmarkLookups(b, `
+        let count = fn(n) {
+            let step = 1
+            let loop = fn(i, acc) { if (i == 0) { return acc } loop(i - step, acc + i) }
+            loop(n, 0)
+        }
+        count(5000)
+    `)
+}
+
+// benchmarkLookups compares evaluating input with resolved slots against looking
+// every name up in the environment chain
+func benchmarkLookups(b *testing.B, input string) {
+    b.Run("slots", func(b *testing.B) {
+        program := parser.New(lexer.New(input)).ParseProgram()
+        resolve(program)
+
+        for b.Loop() { evalBlock(program, object.CreateEnvironment()) }
+    })
+    b.Run("names", func(b *testing.B) {
+        program := parser.New(lexer.New(input)).ParseProgram()
+
+        for b.Loop() { evalBlock(program, object.CreateEnvironment()) }
+    })
+}
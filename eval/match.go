@@ -0,0 +1,100 @@
+package eval
+
+import (
+    "lemur/ast"
+    "lemur/object"
+)
+
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+    subject := Eval(me.Subject, env)
+    if isError(subject) { return subject }
+
+    for _, arm := range me.Arms {
+        armEnv := object.CreateEnclosedEnvironment(env)
+
+        ok, err := matchPattern(arm.Pattern, subject, armEnv)
+        if err != nil { return err }
+        if !ok { continue }
+
+        if arm.Guard != nil {
+            cond := Eval(arm.Guard, armEnv)
+            if isError(cond) { return cond }
+
+            if cond.Type() != object.BooleanType {
+                return createError(InvalidConditionError, "%s", arm.Guard)
+            }
+            if cond == False { continue }
+        }
+
+        return Eval(arm.Body, armEnv)
+    }
+
+    return createError(NoMatchError, "%s", subject)
+}
+
+// matchPattern reports whether val matches pat, binding names into env as it goes
+// (env may hold partial bindings when the match fails), the error is set if the
+// pattern itself is invalid (e.g. an unknown constructor)
+func matchPattern(pat ast.Pattern, val object.Object, env *object.Environment) (bool, object.Object) {
+    switch pat := pat.(type) {
+    case *ast.WildcardPattern:
+        return true, nil
+    case *ast.Identifier:
+        bind(pat, val, env)
+        return true, nil
+    case *ast.LiteralPattern:
+        lit := Eval(pat.Value, env)
+        if isError(lit) { return false, lit }
+
+        return object.Equal(lit, val), nil
+    case *ast.ArrayPattern:
+        arr, ok := val.(*object.Array)
+        if !ok { return false, nil }
+
+        length, n := len(arr.Elements), len(pat.Elements)
+        if length < n || pat.Rest == nil && length != n { return false, nil }
+
+        if ok, err := matchPatterns(pat.Elements, arr.Elements[:n], env); !ok { return false, err }
+
+        if pat.Rest == nil { return true, nil }
+        return matchPattern(pat.Rest, arr.Slice(n, length), env)
+    case *ast.VariantPattern:
+        return matchVariant(pat, val, env)
+    default:
+        return false, nil
+    }
+}
+
+func matchPatterns(pats []ast.Pattern, vals []object.Object, env *object.Environment) (bool, object.Object) {
+    for i, pat := range pats {
+        if ok, err := matchPattern(pat, vals[i], env); !ok { return false, err }
+    }
+
+    return true, nil
+}
+
+func matchVariant(pat *ast.VariantPattern, val object.Object, env *object.Environment) (bool, object.Object) {
+    if pat.Binding {
+        bind(pat.Constructor.(*ast.Identifier), val, env)
+        return true, nil
+    }
+
+    ctor := Eval(pat.Constructor, env)
+    if isError(ctor) { return false, ctor }
+
+    def, ok := ctor.(*object.Struct)
+    if !ok {
+        if pat.Args != nil { return false, createError(InvalidPatternError, "%s is not a constructor", pat.Constructor) }
+        return object.Equal(ctor, val), nil
+    }
+
+    rec, ok := val.(*object.Record)
+    if !ok || rec.Def != def { return false, nil }
+    if pat.Args == nil { return true, nil }
+
+    if len(pat.Args) != len(def.Fields) {
+        return false, createError(InvalidPatternError, "%s has %d fields, got %d", pat.Constructor, len(def.Fields), len(pat.Args))
+    }
+
+    return matchPatterns(pat.Args, rec.Values, env)
+}
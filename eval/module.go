@@ -0,0 +1,108 @@
+package eval
+
+import (
+    "os"
+    "path/filepath"
+    "slices"
+    "strings"
+
+    "lemur/ast"
+    "lemur/lexer"
+    "lemur/object"
+    "lemur/parser"
+)
+
+const (
+    ImportCycleError    = "import cycle"
+    ModuleNotFoundError = "module not found"
+    ModuleParseError    = "failed to parse module"
+    ModuleExtension     = ".lem"
+)
+
+// ModuleLoader caches every module by absolute path so that each file is run once,
+// loading holds the chain of modules currently being run (for cycle detection)
+type ModuleLoader struct {
+    run     func(program ast.Program, env *object.Environment) object.Object
+    modules map[string]*object.Module
+    loading []string
+}
+
+// NewModuleLoader creates a loader running modules with run (e.g. Eval)
+func NewModuleLoader(run func(program ast.Program, env *object.Environment) object.Object) *ModuleLoader {
+    return &ModuleLoader{run: run, modules: map[string]*object.Module{}, loading: []string{}}
+}
+
+var modules *ModuleLoader
+
+func init() {
+    modules = NewModuleLoader(func(program ast.Program, env *object.Environment) object.Object {
+        return Eval(program, env)
+    })
+}
+
+func evalImportStatement(is *ast.ImportStatement, env *object.Environment) object.Object {
+    if err := checkConstants(is, env); err != nil { return err }
+
+    path := resolveModulePath(is.Path.Value, env.Path())
+
+    mod := modules.Load(path)
+    if isError(mod) { return mod }
+
+    env.Set(is.Name(), mod)
+    return mod
+}
+
+func resolveModulePath(path, importer string) string {
+    if filepath.Ext(path) == "" { path += ModuleExtension }
+
+    if !filepath.IsAbs(path) && importer != "" {
+        path = filepath.Join(filepath.Dir(importer), path)
+    }
+
+    if abs, err := filepath.Abs(path); err == nil { return abs }
+    return path
+}
+
+// Load runs the file at path once, the module is named after the file regardless of
+// the alias it is imported as
+func (l *ModuleLoader) Load(path string) object.Object {
+    if mod, ok := l.modules[path]; ok { return mod }
+
+    for i, p := range l.loading {
+        if p != path { continue }
+
+        chain := append(slices.Clone(l.loading[i:]), path)
+        for j := range chain { chain[j] = filepath.Base(chain[j]) }
+        return createError(ImportCycleError, "%s", strings.Join(chain, " -> "))
+    }
+
+    f, err := os.Open(path)
+    if err != nil { return createError(ModuleNotFoundError, "%s", path) }
+    defer f.Close()
+
+    p := parser.New(lexer.NewFromReader(f))
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0 {
+        return createError(ModuleParseError, "%s: %s", path, p.Errors()[0])
+    }
+
+    l.loading = append(l.loading, path)
+    defer func() { l.loading = l.loading[:len(l.loading) - 1] }()
+
+    env := object.CreateModuleEnvironment(path)
+    if res := l.run(program, env); isError(res) { return res }
+
+    name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+    mod := &object.Module{Name: name, Path: path, Exports: map[string]object.Object{}}
+    for _, stmt := range program {
+        export, ok := stmt.(*ast.ExportStatement)
+        if !ok { continue }
+
+        for _, ident := range ast.DeclaredNames(export) {
+            mod.Exports[ident], _ = env.Get(ident)
+        }
+    }
+
+    l.modules[path] = mod
+    return mod
+}
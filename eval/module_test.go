@@ -0,0 +1,122 @@
+package eval
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+
+    "lemur/lexer"
+    "lemur/object"
+    "lemur/parser"
+)
+
+func TestImportStatement(t *testing.T) {
+    dir := writeModules(t, map[string]string{
+        "util.lem": `
+            let secret = 42;
+            export let double = fn(x) { x * 2 };
+            export let answer = secret;
+            export let [lo, hi] = [1, 9];
+            export struct Pair { a, b };
+        `,
+        "lib/strings.lem": `
+            import "../util";
+            export let shout = fn(s) { upper(s) + "!" };
+            export let twice = util.double;
+        `,
+        "counter.lem": `
+            export let id = len(push([], 1));
+        `,
+    })
+
+    tests := []struct{
+        input    string
+        expected any
+    }{
+        {`import "util"; util.double(4)`, 8},
+        {`import "util.lem"; util.answer`, 42},
+        {`import "util" as u; u.double(u.answer)`, 84},
+        {`import "util"; util.hi - util.lo`, 8},
+        {`import "util"; util.Pair(1, 2).b`, 2},
+        {`import "lib/strings"; strings.shout("hi")`, "HI!"},
+        {`import "lib/strings" as s; s.twice(3)`, 6},
+        {`import "util"; type(util)`, "Module"},
+        {`import "util"; import "util" as again; util == again`, true},
+        {`import "counter"; import "./counter.lem" as c; counter.id == c.id`, true},
+    }
+
+    for i, tst := range tests {
+        obj := runModuleEval(t, dir, tst.input)
+
+        switch expd := tst.expected.(type) {
+        case int:
+            res := assertCast[*object.Integer](t, i, obj)
+            assert(t, i, res.Value, int64(expd))
+        case bool:
+            res := assertCast[*object.Boolean](t, i, obj)
+            assert(t, i, res.Value, expd)
+        case string:
+            res := assertCast[*object.String](t, i, obj)
+            assert(t, i, res.Value, expd)
+        }
+    }
+}
+
+func TestImportErrors(t *testing.T) {
+    dir := writeModules(t, map[string]string{
+        "util.lem": "let secret = 1; export let x = 2;",
+        "a.lem":    `import "b"; export let a = 1;`,
+        "b.lem":    `import "a"; export let b = 2;`,
+        "bad.lem":  "let = 1;",
+        "fail.lem": "export let x = 1 + true;",
+    })
+
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        {`import "util"; util.secret`, MemberNotFoundError + ": util.secret"},
+        {`import "util" as u; u.y`, MemberNotFoundError + ": util.y"},
+        {`import "util"; secret`, IdentifierNotFoundError + ": secret"},
+        {`import "missing"`, ModuleNotFoundError + ": " + filepath.Join(dir, "missing.lem")},
+        {`import "a"`, ImportCycleError + ": a.lem -> b.lem -> a.lem"},
+        {`import "fail"`, TypeMismatchError + ": Integer + Boolean"},
+    }
+
+    for i, tst := range tests {
+        obj := runModuleEval(t, dir, tst.input)
+
+        res := assertCast[*object.Error](t, i, obj)
+        assert(t, i, res.Message, tst.expected)
+    }
+
+    obj := runModuleEval(t, dir, `import "bad"`)
+    res := assertCast[*object.Error](t, len(tests), obj)
+    prefix := ModuleParseError + ": " + filepath.Join(dir, "bad.lem")
+    assertMsg(t, len(tests), res.Message[:len(prefix)], prefix, "incorrect error message")
+}
+
+// writeModules creates the given source files in a fresh directory and returns its path
+func writeModules(t *testing.T, files map[string]string) string {
+    dir := t.TempDir()
+    for name, src := range files {
+        path := filepath.Join(dir, name)
+        if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { t.Fatal(err) }
+        if err := os.WriteFile(path, []byte(src), 0o644); err != nil { t.Fatal(err) }
+    }
+
+    return dir
+}
+
+// runModuleEval evaluates input as if it were the file main.lem in dir
+func runModuleEval(t *testing.T, dir, input string) object.Object {
+    t.Helper()
+
+    p := parser.New(lexer.New(input))
+    program := p.ParseProgram()
+    newEnv := func() *object.Environment { return object.CreateModuleEnvironment(filepath.Join(dir, "main.lem")) }
+
+    obj := Eval(program, newEnv())
+    compareRun(t, input, obj, newEnv)
+    return obj
+}
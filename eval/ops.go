@@ -0,0 +1,48 @@
+package eval
+
+import "lemur/object"
+
+// the operations below are shared with the bytecode vm so that both produce the same
+// values and errors
+
+func LookupBuiltin(name string) (object.Object, bool) {
+    b, ok := builtins[name]
+    return b, ok
+}
+
+func LookupPrelude(name string) (object.Object, bool) {
+    obj, ok := prelude[name]
+    return obj, ok
+}
+
+func IsCallable(obj object.Object) bool { return isCallable(obj) }
+
+func Infix(operator string, left, right object.Object) object.Object {
+    return evalInfixExpression(operator, left, right)
+}
+
+func Prefix(operator string, right object.Object) object.Object {
+    return evalPrefixOperator(operator, right)
+}
+
+func Index(left, index object.Object) object.Object { return indexObject(left, index) }
+
+// SliceBound checks that bound can be used to slice left
+func SliceBound(left, bound object.Object) (int64, *object.Error) { return sliceBound(left, bound) }
+
+// Slice slices left with the given start, end and step (nil if missing)
+func Slice(left object.Object, bounds [3]*int64) object.Object { return sliceObject(left, bounds) }
+
+func Member(obj object.Object, name string) object.Object { return lookupMember(obj, name) }
+
+func ConstructRecord(def *object.Struct, args []object.Object, named map[string]object.Object) object.Object {
+    return constructRecord(def, args, named)
+}
+
+// CollectIterable materializes seq, stopping at the first error of a lazy sequence
+func CollectIterable(seq object.Iterable) ([]object.Object, object.Object) { return collectIterable(seq) }
+
+func Throw(val object.Object) *object.Error { return throwValue(val) }
+
+// ResolveModulePath locates the module imported as path from the file importer
+func ResolveModulePath(path, importer string) string { return resolveModulePath(path, importer) }
@@ -0,0 +1,37 @@
+package eval
+
+import "lemur/object"
+
+const (
+    OptionEnum = "Option"
+    ResultEnum = "Result"
+)
+
+// prelude holds global values that are looked up after the environment (so they
+// can be shadowed), namespaced libraries live here instead of in builtins
+var prelude = map[string]object.Object{
+    MathNamespace: mathNamespace,
+    OptionEnum:    optionEnum,
+    ResultEnum:    resultEnum,
+    "Some":        optionEnum.Members["Some"],
+    "None":        optionEnum.Members["None"],
+    "Ok":          resultEnum.Members["Ok"],
+    "Err":         resultEnum.Members["Err"],
+}
+
+// Option and Result are ordinary enums, equivalent to
+//   enum Option { Some(value), None }
+//   enum Result { Ok(value), Err(error) }
+var optionEnum = func() *object.Enum {
+    enum := object.NewEnum(OptionEnum)
+    enum.AddVariant("Some", []string{"value"})
+    enum.AddVariant("None", nil)
+    return enum
+}()
+
+var resultEnum = func() *object.Enum {
+    enum := object.NewEnum(ResultEnum)
+    enum.AddVariant("Ok", []string{"value"})
+    enum.AddVariant("Err", []string{"error"})
+    return enum
+}()
@@ -0,0 +1,88 @@
+package eval
+
+import (
+    "maps"
+    "slices"
+
+    "lemur/ast"
+    "lemur/object"
+)
+
+func evalStructStatement(ss *ast.StructStatement, env *object.Environment) object.Object {
+    if err := checkConstants(ss, env); err != nil { return err }
+
+    def := &object.Struct{Name: ss.Name.Value, Fields: make([]string, 0, len(ss.Fields))}
+    for _, f := range ss.Fields {
+        def.Fields = append(def.Fields, f.Value)
+    }
+
+    bind(ss.Name, def, env)
+    return def
+}
+
+func evalEnumStatement(es *ast.EnumStatement, env *object.Environment) object.Object {
+    if err := checkConstants(es, env); err != nil { return err }
+
+    enum := object.NewEnum(es.Name.Value)
+    for _, v := range es.Variants {
+        var fields []string
+        if v.Fields != nil { fields = make([]string, 0, len(v.Fields)) }
+
+        for _, f := range v.Fields {
+            fields = append(fields, f.Value)
+        }
+        enum.AddVariant(v.Name.Value, fields)
+    }
+
+    bind(es.Name, enum, env)
+    return enum
+}
+
+// constructRecord fills the fields of def from positional arguments first, then named ones
+func constructRecord(def *object.Struct, args []object.Object, named map[string]object.Object) object.Object {
+    if len(args) > len(def.Fields) {
+        return createError(ArgumentMistmatchError, "%s expects %d, got %d", def.Name, len(def.Fields), len(args) + len(named))
+    }
+
+    for _, name := range slices.Sorted(maps.Keys(named)) {
+        i, ok := def.FieldIndex(name)
+        if !ok { return createError(InvalidArgumentError, "%s has no field %s", def.Name, name) }
+        if i < len(args) {
+            return createError(InvalidArgumentError, "%s got more than one value for %s", def.Name, name)
+        }
+    }
+
+    values := make([]object.Object, len(def.Fields))
+    for i, field := range def.Fields {
+        val, ok := named[field]
+        if i < len(args) { val, ok = args[i], true }
+
+        if !ok {
+            return createError(ArgumentMistmatchError, "%s expects %d, got %d", def.Name, len(def.Fields), len(args) + len(named))
+        }
+        values[i] = val
+    }
+
+    return &object.Record{Def: def, Values: values}
+}
+
+func evalWithExpression(we *ast.WithExpression, env *object.Environment) object.Object {
+    left := Eval(we.Left, env)
+    if isError(left) { return left }
+
+    rec, ok := left.(*object.Record)
+    if !ok { return createError(InvalidUpdateError, "%s with {...}", left.Type()) }
+
+    values := slices.Clone(rec.Values)
+    for _, u := range we.Updates {
+        i, ok := rec.Def.FieldIndex(u.Name.Value)
+        if !ok { return createError(MemberNotFoundError, "%s.%s", rec.Def.Name, u.Name) }
+
+        val := Eval(u.Value, env)
+        if isError(val) { return val }
+
+        values[i] = val
+    }
+
+    return &object.Record{Def: rec.Def, Values: values}
+}
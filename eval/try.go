@@ -0,0 +1,65 @@
+package eval
+
+import (
+    "lemur/ast"
+    "lemur/object"
+)
+
+const ThrownErrorKind = "error"
+
+func evalThrowStatement(ts *ast.ThrowStatement, env *object.Environment) object.Object {
+    val := Eval(ts.Value, env)
+    if isError(val) { return val }
+
+    return throwValue(val)
+}
+
+// throwValue creates the error raised by throwing val, a caught exception is rethrown as is
+func throwValue(val object.Object) *object.Error {
+    switch val := val.(type) {
+    case *object.Exception:
+        return val.Err
+    case *object.String:
+        return &object.Error{Kind: ThrownErrorKind, Message: val.Value, Value: val}
+    default:
+        return &object.Error{Kind: ThrownErrorKind, Message: val.String(), Value: val}
+    }
+}
+
+// evalTryExpression yields the value of the try block, or of the catch block if the try
+// block failed, finally always runs and only replaces the result if it fails or returns
+func evalTryExpression(te *ast.TryExpression, env *object.Environment) object.Object {
+    res := Eval(te.Body, env)
+
+    if err, ok := res.(*object.Error); ok && te.Catch != nil {
+        catchEnv := object.CreateEnclosedEnvironment(env)
+        if te.CatchName != nil { bind(te.CatchName, &object.Exception{Err: err}, catchEnv) }
+
+        res = evalBlock(te.Catch.Statements, catchEnv)
+    }
+
+    if te.Finally != nil {
+        fin := Eval(te.Finally, env)
+        if isError(fin) || fin.Type() == object.ReturnType { return fin }
+    }
+
+    return res
+}
+
+func exceptionMember(e *object.Exception, name string) object.Object {
+    switch name {
+    case "message":
+        return &object.String{Value: e.Err.Message}
+    case "kind":
+        return &object.String{Value: e.Err.Kind}
+    case "line":
+        return &object.Integer{Value: int64(e.Err.Line)}
+    case "col":
+        return &object.Integer{Value: int64(e.Err.Col)}
+    case "value":
+        if e.Err.Value == nil { return Null }
+        return e.Err.Value
+    default:
+        return createError(MemberNotFoundError, "%s.%s", object.ExceptionType, name)
+    }
+}
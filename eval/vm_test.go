@@ -0,0 +1,9 @@
+package eval_test
+
+import (
+    "lemur/eval"
+    "lemur/vm"
+)
+
+// every program of the evaluator tests is also run on the vm, which must agree
+func init() { eval.CompareRun = vm.Run }
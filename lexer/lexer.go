@@ -1,32 +1,73 @@
 package lexer
 
 import (
+    "bufio"
     "fmt"
+    "io"
+    "iter"
+    "strings"
 
     "lemur/token"
 )
 
+const eof rune = -1
+
 type Lexer struct {
-    input   string
-    pos     int
-    nextPos int
-    ch      byte
+    reader    io.RuneReader
+    done      bool
+    err       error
+    ch        rune
+    lookahead []rune
+    line      int
+    col       int
 }
 
 func New(input string) *Lexer {
-    l := &Lexer{input: input}
+    return NewFromReader(strings.NewReader(input))
+}
+
+func NewFromReader(r io.Reader) *Lexer {
+    rr, ok := r.(io.RuneReader)
+    if !ok { rr = bufio.NewReader(r) }
+
+    l := &Lexer{reader: rr, line: 1}
     l.readChar()
     return l
 }
 
+// Err returns the first non-EOF error encountered while reading input
+func (l *Lexer) Err() error { return l.err }
+
+// Tokens yields every token up to (but not including) EOF
+func (l *Lexer) Tokens() iter.Seq[token.Token] {
+    return func(yield func(token.Token) bool) {
+        for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+            if !yield(tok) { return }
+        }
+    }
+}
+
 func (l *Lexer) NextToken() (tok token.Token) {
     l.skipWhitespace()
     tok.Literal = string(l.ch)
+    tok.Line, tok.Col = l.line, l.col
 
     switch l.ch {
-    case '\x00': tok.Type = token.EOF
+    case eof:
+        tok.Type = token.EOF
+        tok.Literal = ""
+        return
     case ',': tok.Type = token.Comma
     case ';': tok.Type = token.Semicolon
+    case ':': tok.Type = token.Colon
+    case '.':
+        if l.peekChar() != '.' {
+            tok.Type = token.Dot
+            break
+        }
+        l.readChar()
+        tok.Literal = ".."
+        l.readOperator(&tok)
     case '(': tok.Type = token.LParen
     case ')': tok.Type = token.RParen
     case '{': tok.Type = token.LBrace
@@ -34,15 +75,12 @@ func (l *Lexer) NextToken() (tok token.Token) {
     case '[': tok.Type = token.LBracket
     case ']': tok.Type = token.RBracket
     case '+': tok.Type = token.Plus
-    case '-': tok.Type = token.Minus
+    case '-': l.readOperator(&tok)
     case '*': tok.Type = token.Asterisk
     case '/':
-        if l.nextChar() == '/' {
+        if l.peekChar() == '/' {
             for l.ch != '\n' {
-                if l.ch == '\x00' {
-                    tok.Type = token.EOF
-                    return
-                }
+                if l.ch == eof { return l.NextToken() }
                 l.readChar()
             }
 
@@ -51,7 +89,7 @@ func (l *Lexer) NextToken() (tok token.Token) {
         tok.Type = token.Slash
     case '>': tok.Type = token.GT
     case '<': tok.Type = token.LT
-    case '=', '!', '&', '|':
+    case '=', '!', '&', '|', '?':
         l.readOperator(&tok)
     case '"':
         tok.Type = token.String
@@ -74,19 +112,20 @@ func (l *Lexer) NextToken() (tok token.Token) {
 }
 
 func (l *Lexer) readString() string {
-    startPos := l.pos + 1
+    var out strings.Builder
     for {
         l.readChar()
-        if l.ch == '"' || l.ch == '\x00' { break }
+        if l.ch == '"' || l.ch == eof { break }
+        out.WriteRune(l.ch)
     }
 
-    return l.input[startPos : l.pos]
+    return out.String()
 }
 
 func (l *Lexer) readOperator(tok *token.Token) {
-    cur := string(l.ch)
+    cur := tok.Literal
 
-    literal := string(cur) + string(l.nextChar())
+    literal := cur + string(l.peekChar())
     if isOperator(literal) {
         l.readChar()
         tok.Literal = literal
@@ -100,47 +139,70 @@ func isOperator(op string) bool {
 }
 
 func (l *Lexer) readIdent() string {
-    pos := l.pos
+    var out strings.Builder
     for isAlpha(l.ch) || isDigit(l.ch) {
+        out.WriteRune(l.ch)
         l.readChar()
     }
-    return l.input[pos:l.pos]
+    return out.String()
 }
 
 func (l *Lexer) readNumber(tok *token.Token) {
-    pos := l.pos
+    var out strings.Builder
 
     valid := true
-    for isDigit(l.ch) || isAlpha(l.ch) {
-        if !isDigit(l.ch) { valid = false }
-        l.readChar()
+    readDigits := func() {
+        for isDigit(l.ch) || isAlpha(l.ch) {
+            if !isDigit(l.ch) { valid = false }
+            out.WriteRune(l.ch)
+            l.readChar()
+        }
     }
-    tok.Literal = l.input[pos:l.pos]
 
-    if !valid {
-        tok.Type = token.Illegal
-        return
-    }
     tok.Type = token.Int
+    readDigits()
+
+    if l.ch == '.' && isDigit(l.peekChar()) {
+        tok.Type = token.Float
+        out.WriteRune(l.ch)
+        l.readChar()
+        readDigits()
+    }
+    tok.Literal = out.String()
+
+    if !valid { tok.Type = token.Illegal }
 }
 
 
 func (l *Lexer) readChar() {
-    l.pos = l.nextPos
-    l.nextPos++
+    if l.ch == '\n' { l.line, l.col = l.line + 1, 0 }
+    l.col++
 
-    if l.pos >= len(l.input) {
-        l.ch = '\x00'
+    if len(l.lookahead) > 0 {
+        l.ch = l.lookahead[0]
+        l.lookahead = l.lookahead[1:]
         return
     }
-    l.ch = l.input[l.pos]
+    l.ch = l.readRune()
+}
+
+func (l *Lexer) peekChar() rune {
+    if len(l.lookahead) == 0 {
+        l.lookahead = append(l.lookahead, l.readRune())
+    }
+    return l.lookahead[0]
 }
 
-func (l *Lexer) nextChar() byte {
-    if l.nextPos >= len(l.input) {
-        return '\x00'
+func (l *Lexer) readRune() rune {
+    if l.done { return eof }
+
+    r, _, err := l.reader.ReadRune()
+    if err != nil {
+        if err != io.EOF { l.err = err }
+        l.done = true
+        return eof
     }
-    return l.input[l.nextPos]
+    return r
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -153,10 +215,10 @@ func (l *Lexer) charIsWhiteSpace() bool {
     return l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r'
 }
 
-func isAlpha(ch byte) bool {
+func isAlpha(ch rune) bool {
     return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_'
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
     return ch >= '0' && ch <= '9'
 }
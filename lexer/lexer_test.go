@@ -1,6 +1,8 @@
 package lexer
 
 import (
+    "slices"
+    "strings"
     "testing"
 
     "lemur/token"
@@ -19,6 +21,18 @@ func TestNextToken(t *testing.T) {
         "foo"
         "foo bar"
         [1, 2]
+        a[1:]
+        null ?? a?[0]
+        math.pi 1.5 2.
+        1..5 1..=5 a.b
+        import "lib" as l
+        export let
+        xs |> f
+        match x { _ => 1 }
+        f(...xs)
+        try catch finally throw
+        struct with enum const
+        fn(a: int) -> [int] a-1
     `
     tests := []token.Token{
         createToken("-"),
@@ -77,7 +91,84 @@ func TestNextToken(t *testing.T) {
         createInt("2"),
         createToken("]"),
 
-        createToken("\x00"),
+        createIdent("a"),
+        createToken("["),
+        createInt("1"),
+        createToken(":"),
+        createToken("]"),
+
+        createToken("null"),
+        createToken("??"),
+        createIdent("a"),
+        createToken("?["),
+        createInt("0"),
+        createToken("]"),
+
+        createIdent("math"),
+        createToken("."),
+        createIdent("pi"),
+        {Type: token.Float, Literal: "1.5"},
+        createInt("2"),
+        createToken("."),
+
+        createInt("1"),
+        createToken(".."),
+        createInt("5"),
+        createInt("1"),
+        createToken("..="),
+        createInt("5"),
+        createIdent("a"),
+        createToken("."),
+        createIdent("b"),
+
+        createToken("import"),
+        createString("lib"),
+        createToken("as"),
+        createIdent("l"),
+        createToken("export"),
+        createToken("let"),
+
+        createIdent("xs"),
+        createToken("|>"),
+        createIdent("f"),
+
+        createToken("match"),
+        createIdent("x"),
+        createToken("{"),
+        createIdent("_"),
+        createToken("=>"),
+        createInt("1"),
+        createToken("}"),
+
+        createIdent("f"),
+        createToken("("),
+        createToken("..."),
+        createIdent("xs"),
+        createToken(")"),
+
+        createToken("try"),
+        createToken("catch"),
+        createToken("finally"),
+        createToken("throw"),
+        createToken("struct"),
+        createToken("with"),
+        createToken("enum"),
+        createToken("const"),
+        createToken("fn"),
+        createToken("("),
+        createIdent("a"),
+        createToken(":"),
+        createIdent("int"),
+        createToken(")"),
+        createToken("->"),
+        createToken("["),
+        createIdent("int"),
+        createToken("]"),
+        createIdent("a"),
+        createToken("-"),
+        createInt("1"),
+
+        createToken(""),
     }
 
     l := New(input)
@@ -95,6 +186,48 @@ func TestNextToken(t *testing.T) {
     }
 }
 
+func TestNextTokenFromReader(t *testing.T) {
+    input := "let s = \"a\x00b\"\n\x00 x"
+    tests := []token.Token{
+        at(createToken("let"), 1, 1),
+        at(createIdent("s"), 1, 5),
+        at(createToken("="), 1, 7),
+        at(createString("a\x00b"), 1, 9),
+        {Type: token.Illegal, Literal: "\x00", Line: 2, Col: 1},
+        at(createIdent("x"), 2, 3),
+        at(createToken(""), 2, 4),
+        at(createToken(""), 2, 4),
+    }
+
+    l := NewFromReader(strings.NewReader(input))
+
+    for i, tt := range tests {
+        tok := l.NextToken()
+        if tok != tt {
+            t.Fatalf("token %d: wrong token. Expected %+v, got %+v", i + 1, tt, tok)
+        }
+    }
+}
+
+func TestTokens(t *testing.T) {
+    expected := []token.Token{
+        at(createIdent("add"), 1, 1),
+        at(createToken("("), 1, 4),
+        at(createInt("1"), 1, 5),
+        at(createToken(")"), 1, 6),
+    }
+
+    toks := slices.Collect(New("add(1) // trailing comment").Tokens())
+    if !slices.Equal(toks, expected) {
+        t.Fatalf("wrong tokens. Expected %+v, got %+v", expected, toks)
+    }
+}
+
+func at(tok token.Token, line, col int) token.Token {
+    tok.Line, tok.Col = line, col
+    return tok
+}
+
 func createIdent(l string) token.Token {
     return token.Token{Type: token.Ident, Literal: l}
 }
@@ -111,9 +244,13 @@ func createToken(l string) (t token.Token) {
     t.Literal = l;
     switch l {
 
-    case "\x00": t.Type = token.EOF
+    case "": t.Type = token.EOF
     case ",": t.Type = token.Comma
     case ";": t.Type = token.Semicolon
+    case ":": t.Type = token.Colon
+    case ".": t.Type = token.Dot
+    case "..": t.Type = token.DotDot
+    case "..=": t.Type = token.DotDotEq
     case "(": t.Type = token.LParen
     case ")": t.Type = token.RParen
     case "{": t.Type = token.LBrace
@@ -132,13 +269,32 @@ func createToken(l string) (t token.Token) {
     case "!=": t.Type = token.NotEq
     case "&&": t.Type = token.And
     case "||": t.Type = token.Or
+    case "??": t.Type = token.NullCoalesce
+    case "?[": t.Type = token.OptionalIndex
+    case "|>": t.Type = token.Pipe
+    case "=>": t.Type = token.FatArrow
+    case "->": t.Type = token.Arrow
+    case "...": t.Type = token.Ellipsis
     case "fn": t.Type = token.Function
     case "let": t.Type = token.Let
     case "true": t.Type = token.True
     case "false": t.Type = token.False
+    case "null": t.Type = token.Null
     case "if": t.Type = token.If
     case "else": t.Type = token.Else
     case "return": t.Type = token.Return
+    case "import": t.Type = token.Import
+    case "export": t.Type = token.Export
+    case "as": t.Type = token.As
+    case "match": t.Type = token.Match
+    case "try": t.Type = token.Try
+    case "catch": t.Type = token.Catch
+    case "finally": t.Type = token.Finally
+    case "throw": t.Type = token.Throw
+    case "struct": t.Type = token.Struct
+    case "with": t.Type = token.With
+    case "enum": t.Type = token.Enum
+    case "const": t.Type = token.Const
     }
 
     return
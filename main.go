@@ -1,8 +1,14 @@
 package main
 
 import (
+    "flag"
+    "fmt"
     "os"
+    "path/filepath"
+    "strings"
+
     "lemur/api"
+    "lemur/artifact"
 )
 
 func main() {
@@ -14,6 +20,21 @@ func main() {
         return
     }
 
+    if len(os.Args) > 2 && os.Args[1] == "check" {
+        if !api.CheckFile(os.Args[2]) { os.Exit(1) }
+        return
+    }
+
+    if len(os.Args) > 1 && os.Args[1] == "build" {
+        if !build(os.Args[2:]) { os.Exit(1) }
+        return
+    }
+
+    if len(os.Args) > 2 && os.Args[1] == "vm" {
+        api.RunFromFile(os.Args[2])
+        return
+    }
+
     if len(os.Args) > 1 {
         api.EvalFromFile(os.Args[1])
         return
@@ -21,3 +42,26 @@ func main() {
 
     api.StartREPL(os.Stdin)
 }
+
+// build handles `lemur build file.lem [-o file.lemc] [-fold]`, flags may follow the file
+func build(args []string) bool {
+    fs := flag.NewFlagSet("build", flag.ExitOnError)
+    out := fs.String("o", "", "output file (defaults to the source file with the "+ artifact.Extension + " extension)")
+    fold := fs.Bool("fold", false, "fold operations on literals")
+
+    files := []string{}
+    for len(args) > 0 {
+        fs.Parse(args)
+        if fs.NArg() == 0 { break }
+
+        files = append(files, fs.Arg(0))
+        args = fs.Args()[1:]
+    }
+    if len(files) != 1 {
+        fmt.Println("usage: lemur build file.lem [-o file.lemc] [-fold]")
+        return false
+    }
+
+    if *out == "" { *out = strings.TrimSuffix(files[0], filepath.Ext(files[0])) + artifact.Extension }
+    return api.BuildFile(files[0], *out, *fold)
+}
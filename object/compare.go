@@ -0,0 +1,105 @@
+package object
+
+import (
+    "cmp"
+    "slices"
+)
+
+// Equal reports whether a and b are structurally equal, functions compare by identity
+func Equal(a, b Object) bool {
+    if x, y, ok := numericPair(a, b); ok { return x == y }
+    if a.Type() != b.Type() { return false }
+
+    switch a := a.(type) {
+    case *Integer:
+        return a.Value == b.(*Integer).Value
+    case *Float:
+        return a.Value == b.(*Float).Value
+    case *String:
+        return a.Value == b.(*String).Value
+    case *Boolean:
+        return a.Value == b.(*Boolean).Value
+    case *Null:
+        return true
+    case *Array:
+        other := b.(*Array)
+        if len(a.Elements) != len(other.Elements) { return false }
+
+        for i, el := range a.Elements {
+            if !Equal(el, other.Elements[i]) { return false }
+        }
+        return true
+    case *Record:
+        other := b.(*Record)
+        return a.Def == other.Def && slices.EqualFunc(a.Values, other.Values, Equal)
+    case *Range:
+        other := b.(*Range)
+        if a.Len() != other.Len() { return false }
+        return a.Len() == 0 || a.Start == other.Start && (a.Len() == 1 || a.Step == other.Step)
+    case *Error:
+        return a.Message == b.(*Error).Message
+    default:
+        return a == b
+    }
+}
+
+// Compare orders a and b (-1, 0 or +1), ok is false if the values have no ordering
+func Compare(a, b Object) (res int, ok bool) {
+    if x, y, ok := numericPair(a, b); ok { return cmp.Compare(x, y), true }
+    if a.Type() != b.Type() { return 0, false }
+
+    switch a := a.(type) {
+    case *Integer:
+        return cmp.Compare(a.Value, b.(*Integer).Value), true
+    case *Float:
+        return cmp.Compare(a.Value, b.(*Float).Value), true
+    case *String:
+        return cmp.Compare(a.Value, b.(*String).Value), true
+    case *Boolean:
+        return compareBool(a.Value, b.(*Boolean).Value), true
+    case *Null:
+        return 0, true
+    case *Array:
+        other := b.(*Array)
+
+        for i := range min(len(a.Elements), len(other.Elements)) {
+            res, ok := Compare(a.Elements[i], other.Elements[i])
+            if !ok || res != 0 { return res, ok }
+        }
+        return cmp.Compare(len(a.Elements), len(other.Elements)), true
+    default:
+        return 0, false
+    }
+}
+
+func compareBool(a, b bool) int {
+    switch {
+    case a == b:
+        return 0
+    case a:
+        return 1
+    default:
+        return -1
+    }
+}
+
+// numericPair converts a mixed Integer/Float pair to floats
+func numericPair(a, b Object) (float64, float64, bool) {
+    if a.Type() == b.Type() { return 0, 0, false }
+
+    x, okA := ToFloat(a)
+    y, okB := ToFloat(b)
+    return x, y, okA && okB
+}
+
+func ToFloat(obj Object) (float64, bool) {
+    switch n := obj.(type) {
+    case *Integer:
+        return float64(n.Value), true
+    case *Float:
+        return n.Value, true
+    default:
+        return 0, false
+    }
+}
+
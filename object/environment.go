@@ -1,8 +1,18 @@
 package object
 
 type Environment struct {
-    store map[string]Object
-    outer *Environment
+    store  map[string]Object
+    slots  []slot
+    consts map[string]bool
+    outer  *Environment
+    path   string
+}
+
+// slot holds a variable located by the resolver, its name is kept so that lookups
+// by name still find it
+type slot struct {
+    name string
+    val  Object
 }
 
 func CreateEnvironment() *Environment {
@@ -12,18 +22,80 @@ func CreateEnvironment() *Environment {
     }
 }
 
+// CreateModuleEnvironment creates a root environment for the source file at path
+func CreateModuleEnvironment(path string) *Environment {
+    env := CreateEnvironment()
+    env.path = path
+    return env
+}
+
+// CreateEnclosedEnvironment creates a scope of outer, its store is only allocated
+// when a name without a slot is set
 func CreateEnclosedEnvironment(outer *Environment) *Environment {
-    return &Environment{
-        store: make(map[string]Object),
-        outer: outer,
-    }
+    return &Environment{outer: outer}
 }
 
+func (e *Environment) Outer() *Environment { return e.outer }
+
 func (e *Environment) Get(key string) (Object, bool) {
-    obj, ok := e.store[key]
+    obj, ok := e.get(key)
     if !ok && e.outer != nil { return e.outer.Get(key) }
 
     return obj, ok
 }
 
-func (e *Environment) Set(key string, val Object) { e.store[key] = val }
+func (e *Environment) get(key string) (Object, bool) {
+    for _, s := range e.slots {
+        if s.name == key { return s.val, true }
+    }
+
+    obj, ok := e.store[key]
+    return obj, ok
+}
+
+func (e *Environment) Set(key string, val Object) {
+    for i := range e.slots {
+        if e.slots[i].name == key {
+            e.slots[i].val = val
+            return
+        }
+    }
+
+    if e.store == nil { e.store = make(map[string]Object) }
+    e.store[key] = val
+}
+
+// GetAt returns the variable key from slot of the environment depth levels out, it
+// fails if the slot does not hold key (e.g. it has not been set yet)
+func (e *Environment) GetAt(depth, idx int, key string) (Object, bool) {
+    env := e
+    for ; depth > 0 && env != nil; depth-- { env = env.outer }
+
+    if env == nil || idx >= len(env.slots) || env.slots[idx].name != key { return nil, false }
+    return env.slots[idx].val, true
+}
+
+// SetAt sets the variable key in slot idx of this environment
+func (e *Environment) SetAt(idx int, key string, val Object) {
+    for len(e.slots) <= idx { e.slots = append(e.slots, slot{}) }
+
+    e.slots[idx] = slot{name: key, val: val}
+    delete(e.store, key)
+}
+
+// MarkConst makes key a constant of this scope, the evaluator refuses to rebind it
+// here (enclosed scopes may still shadow it)
+func (e *Environment) MarkConst(key string) {
+    if e.consts == nil { e.consts = map[string]bool{} }
+    e.consts[key] = true
+}
+
+func (e *Environment) IsConst(key string) bool { return e.consts[key] }
+
+// Path returns the source file of the module this environment belongs to (if any)
+func (e *Environment) Path() string {
+    for env := e; env != nil; env = env.outer {
+        if env.path != "" { return env.path }
+    }
+    return ""
+}
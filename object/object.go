@@ -2,6 +2,8 @@ package object
 
 import (
     "fmt"
+    "slices"
+    "strconv"
     "strings"
 
     "lemur/ast"
@@ -16,24 +18,64 @@ type Object interface {
 type ObjectType string // this can be a numeric enum
 
 const (
-    BuiltinType  = "Builtin"
-    FunctionType = "Function"
-    ArrayType	 = "Array"
-    StringType	 = "String"
-    IntegerType  = "Integer"
-    BooleanType  = "Boolean"
-    NullType     = "Null"
-    ReturnType   = "Return"
-    ErrorType    = "Error"
+    NamespaceType = "Namespace"
+    ModuleType    = "Module"
+    BuiltinType   = "Builtin"
+    FunctionType  = "Function"
+    ArrayType     = "Array"
+    RangeType     = "Range"
+    IteratorType  = "Iterator"
+    StringType    = "String"
+    IntegerType   = "Integer"
+    FloatType     = "Float"
+    BooleanType   = "Boolean"
+    NullType      = "Null"
+    EnumType      = "Enum"
+    StructType    = "Struct"
+    RecordType    = "Record"
+    ExceptionType = "Exception"
+    ReturnType    = "Return"
+    ErrorType     = "Error"
 )
 
-type Builtin func(args ...Object) Object
+// Applier invokes a callable object (Function or Builtin) with evaluated arguments,
+// it is provided by the evaluator so that builtins can call back into Lemur code
+type Applier func(fn Object, args ...Object) Object
 
-func (b Builtin) Type() ObjectType { return BuiltinType }
-func (b Builtin) String() string { return "builtin function" }
+type Namespace struct {
+    Name    string
+    Members map[string]Object
+}
+var _ Object = (*Namespace)(nil)
+
+func (n *Namespace) Type() ObjectType { return NamespaceType }
+func (n *Namespace) String() string { return "namespace " + n.Name }
+
+type Module struct {
+    Name    string
+    Path    string
+    Exports map[string]Object
+}
+var _ Object = (*Module)(nil)
+
+func (m *Module) Type() ObjectType { return ModuleType }
+func (m *Module) String() string { return "module " + m.Name }
+
+type BuiltinFunction func(apply Applier, args ...Object) Object
+
+// Builtin is a native function, each is created once under the name it is looked up
+// by so that builtins compare by identity like functions
+type Builtin struct {
+    Name string
+    Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BuiltinType }
+func (b *Builtin) String() string { return "builtin function" }
 
 type Function struct {
-    Parameters []*ast.Identifier
+    Name       string
+    Parameters []*ast.Parameter
     Body       *ast.BlockStatement
     OuterEnv   *Environment
 }
@@ -59,9 +101,65 @@ func (f *Function) String() string {
 
 type Array struct {
     Elements []Object
+    Frozen   bool
+    storage  *arrayStorage
 }
 var _ Object = (*Array)(nil)
 
+// arrayStorage is shared by every array viewing the same backing slice, it
+// tracks how many slots have been claimed so that only the array ending at the
+// claimed mark can append in place (all others copy)
+type arrayStorage struct {
+    cap  int
+    used int
+}
+
+func (a *Array) Push(obj Object) *Array {
+    n := len(a.Elements)
+
+    if !a.Frozen && a.storage != nil && n < cap(a.Elements) && a.storage.cap - cap(a.Elements) + n == a.storage.used {
+        a.storage.used++
+
+        elems := a.Elements[:n + 1]
+        elems[n] = obj
+        return &Array{Elements: elems, storage: a.storage}
+    }
+
+    elems := make([]Object, n + 1, max(2 * n, 4))
+    copy(elems, a.Elements)
+    elems[n] = obj
+
+    return &Array{Elements: elems, storage: &arrayStorage{cap: cap(elems), used: n + 1}}
+}
+
+// Slice shares the backing storage of a, later pushes onto either array copy as needed,
+// slices of a frozen array are frozen as well
+func (a *Array) Slice(start, end int) *Array {
+    return &Array{Elements: a.Elements[start:end], Frozen: a.Frozen, storage: a.storage}
+}
+
+// Freeze returns obj with every array reachable from it frozen, arrays (and records
+// holding them) are copied so that other bindings of obj are unaffected, a frozen
+// array never shares its backing storage with arrays derived from it
+func Freeze(obj Object) Object {
+    switch obj := obj.(type) {
+    case *Array:
+        if obj.Frozen { return obj }
+
+        elems := make([]Object, 0, len(obj.Elements))
+        for _, el := range obj.Elements { elems = append(elems, Freeze(el)) }
+        return &Array{Elements: elems, Frozen: true}
+    case *Record:
+        vals := make([]Object, 0, len(obj.Values))
+        for _, val := range obj.Values { vals = append(vals, Freeze(val)) }
+
+        if slices.Equal(vals, obj.Values) { return obj }
+        return &Record{Def: obj.Def, Values: vals}
+    default:
+        return obj
+    }
+}
+
 func (a *Array) Type() ObjectType { return ArrayType }
 func (a *Array) String() string {
     var out strings.Builder
@@ -94,6 +192,19 @@ var _ Object = (*Integer)(nil)
 func (i *Integer) Type() ObjectType { return IntegerType }
 func (i *Integer) String() string { return fmt.Sprintf("%d", i.Value) }
 
+type Float struct {
+    Value float64
+}
+var _ Object = (*Float)(nil)
+
+func (f *Float) Type() ObjectType { return FloatType }
+func (f *Float) String() string {
+    str := strconv.FormatFloat(f.Value, 'g', -1, 64)
+    if strings.ContainsAny(str, ".eIN") { return str }
+
+    return str + ".0"
+}
+
 type Boolean struct {
     Value bool
 }
@@ -118,10 +229,38 @@ var _ Object = (*Return)(nil)
 func (r *Return) Type() ObjectType { return ReturnType }
 func (r *Return) String() string { return r.Value.String() }
 
-type Error struct { // attach token info to this
+// Error is propagated up through evaluation until caught, Line and Col locate the
+// node that produced it (zero if unknown) and Value holds the payload of a throw
+type Error struct {
+    Kind    string
     Message string
+    Line    int
+    Col     int
+    Value   Object
 }
 var _ Object = (*Error)(nil)
 
 func (e *Error) Type() ObjectType { return ErrorType }
-func (e *Error) String() string { return "Error: " + e.Message }
+func (e *Error) String() string {
+    if e.Line == 0 { return "Error: " + e.Message }
+    return fmt.Sprintf("Error: %s (line %d, col %d)", e.Message, e.Line, e.Col)
+}
+
+// At returns a copy of e positioned at line and col, e itself is returned if it
+// already has a position (or line is zero) as errors may be shared or caught
+func (e *Error) At(line, col int) *Error {
+    if e.Line != 0 || line == 0 { return e }
+
+    pos := *e
+    pos.Line, pos.Col = line, col
+    return &pos
+}
+
+// Exception is a caught Error, it is an ordinary value which can be inspected or rethrown
+type Exception struct {
+    Err *Error
+}
+var _ Object = (*Exception)(nil)
+
+func (e *Exception) Type() ObjectType { return ExceptionType }
+func (e *Exception) String() string { return "exception(" + e.Err.Message + ")" }
@@ -0,0 +1,91 @@
+package object
+
+import (
+    "slices"
+    "strings"
+)
+
+// Struct is a record type declared with 'struct', calling it constructs a Record,
+// enum variants with fields are also structs (Enum is the name of the enum)
+type Struct struct {
+    Name   string
+    Fields []string
+    Enum   string
+}
+
+func (s *Struct) Type() ObjectType { return StructType }
+func (s *Struct) String() string {
+    if s.Enum != "" { return "variant " + s.Enum + "." + s.Name }
+    return "struct " + s.Name
+}
+
+// TypeName is the name reported for records of this struct
+func (s *Struct) TypeName() string {
+    if s.Enum != "" { return s.Enum }
+    return s.Name
+}
+
+func (s *Struct) FieldIndex(name string) (int, bool) {
+    i := slices.Index(s.Fields, name)
+    return i, i >= 0
+}
+
+// Record is an immutable instance of a Struct, Values are ordered as Def.Fields
+type Record struct {
+    Def    *Struct
+    Values []Object
+}
+
+func (r *Record) Type() ObjectType { return RecordType }
+func (r *Record) String() string {
+    if r.Def.Enum != "" {
+        if len(r.Values) == 0 { return r.Def.Name }
+
+        values := make([]string, 0, len(r.Values))
+        for _, val := range r.Values {
+            values = append(values, val.String())
+        }
+        return r.Def.Name + "(" + strings.Join(values, ", ") + ")"
+    }
+
+    fields := make([]string, 0, len(r.Values))
+    for i, val := range r.Values {
+        fields = append(fields, r.Def.Fields[i] + ": " + val.String())
+    }
+
+    return r.Def.Name + " {" + strings.Join(fields, ", ") + "}"
+}
+
+func (r *Record) Get(name string) (Object, bool) {
+    i, ok := r.Def.FieldIndex(name)
+    if !ok { return nil, false }
+
+    return r.Values[i], true
+}
+
+// Enum is a tagged union, Members maps each variant name to its constructor (a Struct)
+// or, for variants without fields, to the single Record value of that variant
+type Enum struct {
+    Name     string
+    Variants []string
+    Members  map[string]Object
+}
+
+func (e *Enum) Type() ObjectType { return EnumType }
+func (e *Enum) String() string { return "enum " + e.Name }
+
+// AddVariant registers a variant, a nil fields slice declares a field-less variant
+func (e *Enum) AddVariant(name string, fields []string) {
+    def := &Struct{Name: name, Fields: fields, Enum: e.Name}
+
+    e.Variants = append(e.Variants, name)
+    if fields == nil {
+        e.Members[name] = &Record{Def: def, Values: []Object{}}
+    } else {
+        e.Members[name] = def
+    }
+}
+
+func NewEnum(name string) *Enum {
+    return &Enum{Name: name, Variants: []string{}, Members: map[string]Object{}}
+}
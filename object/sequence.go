@@ -0,0 +1,74 @@
+package object
+
+import (
+    "fmt"
+    "iter"
+)
+
+// Iterable is implemented by objects whose elements can be visited in order
+// without materializing them (lazy objects yield an Error to signal failure)
+type Iterable interface {
+    Object
+    All() iter.Seq[Object]
+}
+
+var (
+    _ Iterable = (*Array)(nil)
+    _ Iterable = (*Range)(nil)
+    _ Iterable = (*Iterator)(nil)
+)
+
+func (a *Array) All() iter.Seq[Object] {
+    return func(yield func(Object) bool) {
+        for _, el := range a.Elements {
+            if !yield(el) { return }
+        }
+    }
+}
+
+// Range is a lazy arithmetic sequence from Start up to (not including) End
+type Range struct {
+    Start int64
+    End   int64
+    Step  int64
+}
+
+func (r *Range) Type() ObjectType { return RangeType }
+func (r *Range) String() string {
+    if r.Step == 1 { return fmt.Sprintf("%d..%d", r.Start, r.End) }
+    return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// Len is the number of elements, the span is worked out in uint64 as it may not fit
+// in an int64 (nor may the length of a range over every integer)
+func (r *Range) Len() uint64 {
+    if r.Step > 0 && r.Start < r.End {
+        return (uint64(r.End) - uint64(r.Start) - 1) / uint64(r.Step) + 1
+    }
+    if r.Step < 0 && r.Start > r.End {
+        return (uint64(r.Start) - uint64(r.End) - 1) / -uint64(r.Step) + 1
+    }
+
+    return 0
+}
+
+// At returns the element at index i, which must be in [0, Len()), the arithmetic
+// wraps but the element itself always fits
+func (r *Range) At(i uint64) *Integer { return &Integer{Value: int64(uint64(r.Start) + i * uint64(r.Step))} }
+
+func (r *Range) All() iter.Seq[Object] {
+    return func(yield func(Object) bool) {
+        for i := range r.Len() {
+            if !yield(r.At(i)) { return }
+        }
+    }
+}
+
+// Iterator wraps a lazily evaluated sequence, Seq may be iterated more than once
+type Iterator struct {
+    Seq iter.Seq[Object]
+}
+
+func (it *Iterator) Type() ObjectType { return IteratorType }
+func (it *Iterator) String() string { return "<iterator>" }
+func (it *Iterator) All() iter.Seq[Object] { return it.Seq }
@@ -4,11 +4,12 @@ import (
     "fmt"
     "slices"
     "strconv"
+    "unicode"
+    "unicode/utf8"
 
     "lemur/ast"
     "lemur/lexer"
     "lemur/token"
-    "lemur/util"
 )
 
 const (
@@ -16,14 +17,34 @@ const (
     IllegalTokenError            = "illegal token"
     NonIdentifierAssignmentError = "non-identifier expression after let keyword"
     NonIdentifierParameterError  = "non-identifier expression in function parameters"
+    NonIdentifierMemberError     = "non-identifier member after '.'"
+    InvalidModuleNameError       = "cannot derive module name from import path (use 'as')"
+    NonDeclarationExportError    = "export must be followed by a declaration"
+    NestedExportError            = "export is only allowed at the top level"
+    InvalidPipeTargetError       = "right side of '|>' must be a function or call"
+    InvalidPatternError          = "invalid pattern"
+    RestPatternPositionError     = "rest pattern must be the last element of an array pattern"
+    EOFBeforeMatchEndError       = "reached EOF before closing brace in match expression (missing '}')"
+    RestParameterPositionError   = "rest parameter must be the last parameter"
+    PositionalAfterNamedError    = "positional argument after named argument"
+    TryWithoutHandlerError       = "try must be followed by catch or finally"
+    NonIdentifierFieldError      = "expected field name"
+    DuplicateFieldError          = "duplicate field"
+    NonIdentifierVariantError    = "expected variant name"
+    DuplicateVariantError        = "duplicate variant"
+    ConstRedeclarationError      = "cannot redeclare constant"
+    InvalidTypeError             = "expected type"
 )
 
 const (
     _ int = iota
     Lowest
+    Pipe
+    Coalesce
     AndOr
     Equals
     LessGreater
+    Range
     Sum
     Product
     Prefix
@@ -32,18 +53,25 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
-    token.And:      AndOr,
-    token.Or:       AndOr,
-    token.Eq:       Equals,
-    token.NotEq:    Equals,
-    token.LT:       LessGreater,
-    token.GT:       LessGreater,
-    token.Plus:     Sum,
-    token.Minus:    Sum,
-    token.Slash:    Product,
-    token.Asterisk: Product,
-    token.LParen:   Call,
-    token.LBracket: Index,
+    token.Pipe:          Pipe,
+    token.NullCoalesce:  Coalesce,
+    token.And:           AndOr,
+    token.Or:            AndOr,
+    token.Eq:            Equals,
+    token.NotEq:         Equals,
+    token.LT:            LessGreater,
+    token.GT:            LessGreater,
+    token.DotDot:        Range,
+    token.DotDotEq:      Range,
+    token.Plus:          Sum,
+    token.Minus:         Sum,
+    token.Slash:         Product,
+    token.Asterisk:      Product,
+    token.LParen:        Call,
+    token.LBracket:      Index,
+    token.OptionalIndex: Index,
+    token.Dot:           Index,
+    token.With:          Index,
 }
 
 type (
@@ -58,6 +86,9 @@ type Parser struct {
     invalid   bool
     curToken  token.Token
 
+    // constants holds the names declared const in each enclosing block (innermost last)
+    constants []map[string]bool
+
     prefixParseFns map[token.TokenType]prefixParseFn
     infixParseFns map[token.TokenType]infixParseFn
 }
@@ -73,6 +104,7 @@ func New(l *lexer.Lexer) *Parser {
     p := &Parser{
         lex: l,
         errors: []string{},
+        constants: []map[string]bool{{}},
     }
     p.readToken()
 
@@ -82,13 +114,17 @@ func New(l *lexer.Lexer) *Parser {
     p.registerPrefix(token.LBracket, p.parseArrayLiteral)
     p.registerPrefix(token.String, p.parseStringLiteral)
     p.registerPrefix(token.Int, p.parseIntegerLiteral)
+    p.registerPrefix(token.Float, p.parseFloatLiteral)
     p.registerPrefix(token.True, p.parseBoolean)
     p.registerPrefix(token.False, p.parseBoolean)
+    p.registerPrefix(token.Null, p.parseNull)
     p.registerPrefix(token.LParen, p.parseGroupedExpression)
     p.registerPrefix(token.Bang, p.parsePrefixOperator)
     p.registerPrefix(token.Minus, p.parsePrefixOperator)
     p.registerPrefix(token.If, p.parseConditionalExpression)
     p.registerPrefix(token.Function, p.parseFunctionLiteral)
+    p.registerPrefix(token.Match, p.parseMatchExpression)
+    p.registerPrefix(token.Try, p.parseTryExpression)
 
     p.infixParseFns = make(map[token.TokenType]infixParseFn)
     p.registerInfix(token.Plus, p.parseInfixExpression)
@@ -99,10 +135,17 @@ func New(l *lexer.Lexer) *Parser {
     p.registerInfix(token.NotEq, p.parseInfixExpression)
     p.registerInfix(token.And, p.parseInfixExpression)
     p.registerInfix(token.Or, p.parseInfixExpression)
+    p.registerInfix(token.NullCoalesce, p.parseInfixExpression)
     p.registerInfix(token.LT, p.parseInfixExpression)
     p.registerInfix(token.GT, p.parseInfixExpression)
+    p.registerInfix(token.DotDot, p.parseInfixExpression)
+    p.registerInfix(token.DotDotEq, p.parseInfixExpression)
+    p.registerInfix(token.Pipe, p.parsePipeExpression)
     p.registerInfix(token.LParen, p.parseCallExpression)
     p.registerInfix(token.LBracket, p.parseIndexExpression)
+    p.registerInfix(token.OptionalIndex, p.parseIndexExpression)
+    p.registerInfix(token.Dot, p.parseMemberExpression)
+    p.registerInfix(token.With, p.parseWithExpression)
 
     return p
 }
@@ -112,6 +155,7 @@ func (p *Parser) ParseProgram() ast.Program {
 
     for !p.curTokenIs(token.EOF) {
         stmt := p.parseStatement()
+        p.declare(stmt)
 
         if p.invalid { break }
         program = append(program, stmt)
@@ -127,10 +171,20 @@ func (p *Parser) Errors() []string {
 
 func (p *Parser) parseStatement() ast.Statement {
     switch p.curToken.Type {
-    case token.Let:
+    case token.Let, token.Const:
         return p.parseLetStatement()
     case token.Return:
         return p.parseReturnStatement()
+    case token.Throw:
+        return p.parseThrowStatement()
+    case token.Struct:
+        return p.parseStructStatement()
+    case token.Enum:
+        return p.parseEnumStatement()
+    case token.Import:
+        return p.parseImportStatement()
+    case token.Export:
+        return p.parseExportStatement()
     case token.LBrace:
         return p.parseBlockStatement()
     default:
@@ -145,6 +199,9 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
     }
     p.readToken()
 
+    p.constants = append(p.constants, map[string]bool{})
+    defer func() { p.constants = p.constants[:len(p.constants) - 1] }()
+
     for !p.curTokenIs(token.RBrace) {
         if p.curTokenIs(token.EOF) {
             p.raiseError(EOFBeforeClosingBraceError)
@@ -152,6 +209,7 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
         }
 
         stmt := p.parseStatement()
+        p.declare(stmt)
 
         if stmt == nil { continue }
         block.Statements = append(block.Statements, stmt)
@@ -161,15 +219,44 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
     return block
 }
 
+// declare records the constants bound by stmt in the current block, rebinding a
+// name that is already constant in the same block is an error (inner blocks may shadow it)
+func (p *Parser) declare(stmt ast.Statement) {
+    if p.invalid { return }
+
+    scope := p.constants[len(p.constants) - 1]
+    for _, name := range ast.DeclaredNames(stmt) {
+        if scope[name] {
+            p.raiseError(fmt.Sprintf("%s: %s", ConstRedeclarationError, name))
+            return
+        }
+    }
+
+    if export, ok := stmt.(*ast.ExportStatement); ok { stmt = export.Statement }
+    if ls, ok := stmt.(*ast.LetStatement); ok && ls.IsConst() {
+        for _, name := range ast.DeclaredNames(ls) { scope[name] = true }
+    }
+}
+
 func (p *Parser) parseLetStatement() *ast.LetStatement {
     stmt := &ast.LetStatement{Token: p.curToken}
     p.readToken()
 
-    if !p.curTokenIs(token.Ident) {
+    switch {
+    case p.curTokenIs(token.Ident):
+        stmt.Name, _ = p.parseIdentifier().(*ast.Identifier)
+    case p.curTokenIs(token.LBracket):
+        stmt.Pattern = p.parseArrayPattern()
+        if stmt.Pattern == nil { return nil }
+    default:
         p.raiseError(NonIdentifierAssignmentError)
         return nil
     }
-    stmt.Name, _ = p.parseIdentifier().(*ast.Identifier)
+
+    if p.skipToken(token.Colon) {
+        stmt.Type = p.parseType()
+        if stmt.Type == nil { return nil }
+    }
 
     if !p.expectRead(token.Assign) { return nil }
     stmt.Value = p.parseExpression(Lowest)
@@ -179,6 +266,58 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
     return stmt
 }
 
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+    stmt := &ast.ImportStatement{Token: p.curToken}
+    p.readToken()
+
+    if !p.curTokenIs(token.String) {
+        p.expectError(token.String)
+        return nil
+    }
+    stmt.Path, _ = p.parseStringLiteral().(*ast.StringLiteral)
+
+    if p.skipToken(token.As) {
+        if !p.curTokenIs(token.Ident) {
+            p.expectError(token.Ident)
+            return nil
+        }
+        stmt.Alias, _ = p.parseIdentifier().(*ast.Identifier)
+    } else if !isIdentifier(stmt.Name()) {
+        p.raiseError(fmt.Sprintf("%s: %q", InvalidModuleNameError, stmt.Path.Value))
+        return nil
+    }
+
+    if p.curTokenIs(token.Semicolon) { p.readToken() }
+
+    return stmt
+}
+
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+    stmt := &ast.ExportStatement{Token: p.curToken}
+    p.readToken()
+
+    // only the top level block is exported by modules
+    if len(p.constants) > 1 {
+        p.raiseError(NestedExportError)
+        return nil
+    }
+
+    switch {
+    case p.curTokenIs(token.Let), p.curTokenIs(token.Const):
+        stmt.Statement = p.parseLetStatement()
+    case p.curTokenIs(token.Struct):
+        stmt.Statement = p.parseStructStatement()
+    case p.curTokenIs(token.Enum):
+        stmt.Statement = p.parseEnumStatement()
+    default:
+        p.raiseError(NonDeclarationExportError)
+        return nil
+    }
+    if p.invalid { return nil }
+
+    return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
     stmt := &ast.ReturnStatement{Token: p.curToken}
     p.readToken()
@@ -189,6 +328,107 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
     return stmt
 }
 
+func (p *Parser) parseStructStatement() *ast.StructStatement {
+    stmt := &ast.StructStatement{Token: p.curToken}
+    p.readToken()
+
+    if !p.curTokenIs(token.Ident) {
+        p.expectError(token.Ident)
+        return nil
+    }
+    stmt.Name, _ = p.parseIdentifier().(*ast.Identifier)
+
+    if !p.expectRead(token.LBrace) { return nil }
+    stmt.Fields = p.parseFieldNames(stmt.Name.Value, token.RBrace)
+    if stmt.Fields == nil { return nil }
+
+    if p.curTokenIs(token.Semicolon) { p.readToken() }
+
+    return stmt
+}
+
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+    stmt := &ast.EnumStatement{Token: p.curToken, Variants: []*ast.EnumVariant{}}
+    p.readToken()
+
+    if !p.curTokenIs(token.Ident) {
+        p.expectError(token.Ident)
+        return nil
+    }
+    stmt.Name, _ = p.parseIdentifier().(*ast.Identifier)
+
+    if !p.expectRead(token.LBrace) { return nil }
+    for !p.skipToken(token.RBrace) {
+        if !p.curTokenIs(token.Ident) {
+            p.raiseError(fmt.Sprintf("%s: %v", NonIdentifierVariantError, p.curToken.Type))
+            return nil
+        }
+        v := &ast.EnumVariant{}
+        v.Name, _ = p.parseIdentifier().(*ast.Identifier)
+
+        for _, other := range stmt.Variants {
+            if other.Name.Value != v.Name.Value { continue }
+
+            p.raiseError(fmt.Sprintf("%s: %s.%s", DuplicateVariantError, stmt.Name, v.Name))
+            return nil
+        }
+
+        if p.skipToken(token.LParen) {
+            v.Fields = p.parseFieldNames(stmt.Name.Value + "." + v.Name.Value, token.RParen)
+            if v.Fields == nil { return nil }
+        }
+        stmt.Variants = append(stmt.Variants, v)
+
+        if !p.skipToken(token.Comma) && !p.curTokenIs(token.RBrace) {
+            p.expectError(token.RBrace)
+            return nil
+        }
+    }
+    if p.curTokenIs(token.Semicolon) { p.readToken() }
+
+    return stmt
+}
+
+// parseFieldNames parses comma separated, unique field names up to and including end
+// (a trailing comma is allowed), owner is used in error messages
+func (p *Parser) parseFieldNames(owner string, end token.TokenType) []*ast.Identifier {
+    fields := []*ast.Identifier{}
+
+    for !p.skipToken(end) {
+        if !p.curTokenIs(token.Ident) {
+            p.raiseError(fmt.Sprintf("%s: %v", NonIdentifierFieldError, p.curToken.Type))
+            return nil
+        }
+        field, _ := p.parseIdentifier().(*ast.Identifier)
+
+        for _, f := range fields {
+            if f.Value != field.Value { continue }
+
+            p.raiseError(fmt.Sprintf("%s: %s.%s", DuplicateFieldError, owner, field))
+            return nil
+        }
+        fields = append(fields, field)
+
+        if !p.skipToken(token.Comma) && !p.curTokenIs(end) {
+            p.expectError(end)
+            return nil
+        }
+    }
+
+    return fields
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+    stmt := &ast.ThrowStatement{Token: p.curToken}
+    p.readToken()
+
+    stmt.Value = p.parseExpression(Lowest)
+    if stmt.Value == nil { return nil }
+    if p.curTokenIs(token.Semicolon) { p.readToken() }
+
+    return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
     stmt := &ast.ExpressionStatement{Token: p.curToken}
 
@@ -281,15 +521,87 @@ func (p *Parser) parseArrayLiteral() ast.Expression  {
 }
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-    exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+    tok := p.curToken
+    p.readToken()
+
+    var index ast.Expression
+    if !p.curTokenIs(token.Colon) {
+        index = p.parseExpression(Lowest)
+        if index == nil { return nil }
+    }
+
+    optional := tok.Type == token.OptionalIndex
+    if !p.curTokenIs(token.Colon) {
+        if !p.expectRead(token.RBracket) { return nil }
+        return &ast.IndexExpression{Token: tok, Left: left, Index: index, Optional: optional}
+    }
+
+    return p.parseSliceExpression(tok, left, index)
+}
+
+func (p *Parser) parseSliceExpression(tok token.Token, left, start ast.Expression) ast.Expression {
+    exp := &ast.SliceExpression{
+        Token: tok,
+        Left: left,
+        Start: start,
+        Optional: tok.Type == token.OptionalIndex,
+    }
     p.readToken()
 
-    exp.Index = p.parseExpression(Lowest)
+    if !p.curTokenIs(token.Colon) && !p.curTokenIs(token.RBracket) {
+        exp.End = p.parseExpression(Lowest)
+        if exp.End == nil { return nil }
+    }
+
+    if p.skipToken(token.Colon) && !p.curTokenIs(token.RBracket) {
+        exp.Step = p.parseExpression(Lowest)
+        if exp.Step == nil { return nil }
+    }
 
     if !p.expectRead(token.RBracket) { return nil }
     return exp
 }
 
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+    exp := &ast.MemberExpression{Token: p.curToken, Left: left}
+    p.readToken()
+
+    if !p.curTokenIs(token.Ident) {
+        p.raiseError(NonIdentifierMemberError)
+        return nil
+    }
+    exp.Member, _ = p.parseIdentifier().(*ast.Identifier)
+
+    return exp
+}
+
+func (p *Parser) parseWithExpression(left ast.Expression) ast.Expression {
+    exp := &ast.WithExpression{Token: p.curToken, Left: left, Updates: []*ast.FieldValue{}}
+    p.readToken()
+
+    if !p.expectRead(token.LBrace) { return nil }
+    for !p.skipToken(token.RBrace) {
+        if !p.curTokenIs(token.Ident) {
+            p.raiseError(fmt.Sprintf("%s: %v", NonIdentifierFieldError, p.curToken.Type))
+            return nil
+        }
+        fv := &ast.FieldValue{}
+        fv.Name, _ = p.parseIdentifier().(*ast.Identifier)
+
+        if !p.expectRead(token.Colon) { return nil }
+        fv.Value = p.parseExpression(Lowest)
+        if fv.Value == nil { return nil }
+
+        exp.Updates = append(exp.Updates, fv)
+        if !p.skipToken(token.Comma) && !p.curTokenIs(token.RBrace) {
+            p.expectError(token.RBrace)
+            return nil
+        }
+    }
+
+    return exp
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
     s := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
     p.readToken()
@@ -312,6 +624,21 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
     return l
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+    l := &ast.FloatLiteral{Token: p.curToken}
+
+    val, err := strconv.ParseFloat(p.curToken.Literal, 64)
+    if err != nil {
+        msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+        p.errors = append(p.errors, msg)
+        return nil
+    }
+    l.Value = val
+
+    p.readToken()
+    return l
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
     b := &ast.BooleanLiteral{Token: p.curToken, Value: p.curTokenIs(token.True)}
     p.readToken()
@@ -319,27 +646,118 @@ func (p *Parser) parseBoolean() ast.Expression {
     return b
 }
 
+func (p *Parser) parseNull() ast.Expression {
+    n := &ast.NullLiteral{Token: p.curToken}
+    p.readToken()
+
+    return n
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
-    l := &ast.FunctionLiteral{Token: p.curToken, Parameters: []*ast.Identifier{}}
+    l := &ast.FunctionLiteral{Token: p.curToken, Parameters: []*ast.Parameter{}}
     p.readToken()
 
     if !p.expectRead(token.LParen) { return nil }
     if !p.skipToken(token.RParen) {
-        var ok bool
-        if l.Parameters, ok = util.Collect[*ast.Identifier](p.parseExpressionList); !ok {
-            p.raiseError(NonIdentifierParameterError)
-            return nil
+        for {
+            param := p.parseParameter()
+            if param == nil { return nil }
+            l.Parameters = append(l.Parameters, param)
+
+            if param.Rest && !p.curTokenIs(token.RParen) {
+                p.raiseError(RestParameterPositionError)
+                return nil
+            }
+            if !p.skipToken(token.Comma) { break }
         }
 
         if !p.expectRead(token.RParen) { return nil }
     }
 
+    if p.skipToken(token.Arrow) {
+        l.ReturnType = p.parseType()
+        if l.ReturnType == nil { return nil }
+    }
+
     if !p.curTokenIs(token.LBrace) { return nil }
     l.Body = p.parseBlockStatement()
 
     return l
 }
 
+// parseParameter parses an identifier or destructuring array pattern with an optional
+// type ('x: int') and default value ('x = 1'), or a rest parameter ('...xs')
+func (p *Parser) parseParameter() *ast.Parameter {
+    param := &ast.Parameter{Token: p.curToken}
+    param.Rest = p.skipToken(token.Ellipsis)
+
+    switch {
+    case p.curTokenIs(token.Ident):
+        param.Pattern, _ = p.parseIdentifier().(*ast.Identifier)
+    case p.curTokenIs(token.LBracket) && !param.Rest:
+        param.Pattern = p.parsePattern()
+        if param.Pattern == nil { return nil }
+    default:
+        p.raiseError(NonIdentifierParameterError)
+        return nil
+    }
+
+    if p.skipToken(token.Colon) {
+        param.Type = p.parseType()
+        if param.Type == nil { return nil }
+    }
+    if param.Rest { return param }
+
+    if p.skipToken(token.Assign) {
+        param.Default = p.parseExpression(Lowest)
+        if param.Default == nil { return nil }
+    }
+
+    return param
+}
+
+// parseType parses a type annotation, a type name ('int', 'Point'), an array type
+// ('[int]') or a function type ('fn(int, int) -> bool')
+func (p *Parser) parseType() ast.TypeExpr {
+    switch p.curToken.Type {
+    case token.Ident, token.Null:
+        t := &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+        p.readToken()
+        return t
+    case token.LBracket:
+        t := &ast.ArrayType{Token: p.curToken}
+        p.readToken()
+
+        t.Element = p.parseType()
+        if t.Element == nil || !p.expectRead(token.RBracket) { return nil }
+        return t
+    case token.Function:
+        t := &ast.FunctionType{Token: p.curToken, Parameters: []ast.TypeExpr{}}
+        p.readToken()
+
+        if !p.expectRead(token.LParen) { return nil }
+        for !p.skipToken(token.RParen) {
+            param := p.parseType()
+            if param == nil { return nil }
+            t.Parameters = append(t.Parameters, param)
+
+            if !p.skipToken(token.Comma) && !p.curTokenIs(token.RParen) {
+                p.expectError(token.RParen)
+                return nil
+            }
+        }
+
+        if p.skipToken(token.Arrow) {
+            t.Return = p.parseType()
+            if t.Return == nil { return nil }
+        }
+        return t
+    default:
+        p.raiseError(fmt.Sprintf("%s: %v", InvalidTypeError, p.curToken.Type))
+        return nil
+    }
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
     exp := &ast.CallExpression{
         Token: p.curToken,
@@ -349,12 +767,73 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
     p.readToken()
 
     if p.skipToken(token.RParen) { return exp }
-    exp.Arguments = slices.Collect(p.parseExpressionList)
+
+    named := false
+    for {
+        arg := p.parseCallArgument()
+        if arg == nil { return nil }
+
+        _, isNamed := arg.(*ast.NamedArgument)
+        if named && !isNamed {
+            p.raiseError(PositionalAfterNamedError)
+            return nil
+        }
+        named = named || isNamed
+
+        exp.Arguments = append(exp.Arguments, arg)
+        if !p.skipToken(token.Comma) { break }
+    }
 
     if !p.expectRead(token.RParen) { return nil }
     return exp
 }
 
+// parseCallArgument parses a positional argument, a spread argument ('...xs')
+// or a named argument ('x: 1')
+func (p *Parser) parseCallArgument() ast.Expression {
+    if p.curTokenIs(token.Ellipsis) {
+        exp := &ast.SpreadExpression{Token: p.curToken}
+        p.readToken()
+
+        exp.Value = p.parseExpression(Lowest)
+        if exp.Value == nil { return nil }
+
+        return exp
+    }
+
+    arg := p.parseExpression(Lowest)
+    if arg == nil { return nil }
+
+    ident, ok := arg.(*ast.Identifier)
+    if !ok || !p.skipToken(token.Colon) { return arg }
+
+    exp := &ast.NamedArgument{Name: ident, Value: p.parseExpression(Lowest)}
+    if exp.Value == nil { return nil }
+
+    return exp
+}
+
+// parsePipeExpression desugars 'x |> f(a)' into 'f(x, a)' and 'x |> f' into 'f(x)'
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+    tok := p.curToken
+    precedence := p.curPrecedence()
+    p.readToken()
+
+    right := p.parseExpression(precedence)
+    if right == nil { return nil }
+
+    switch target := right.(type) {
+    case *ast.CallExpression:
+        target.Arguments = append([]ast.Expression{left}, target.Arguments...)
+        return target
+    case *ast.Identifier, *ast.MemberExpression, *ast.FunctionLiteral:
+        return &ast.CallExpression{Token: tok, Function: target, Arguments: []ast.Expression{left}}
+    default:
+        p.raiseError(fmt.Sprintf("%s: %s", InvalidPipeTargetError, right))
+        return nil
+    }
+}
+
 func (p *Parser) parseConditionalExpression() ast.Expression {
     exp := &ast.ConditionalExpression{Token: p.curToken}
     p.readToken()
@@ -376,6 +855,197 @@ func (p *Parser) parseConditionalExpression() ast.Expression {
     return exp
 }
 
+func (p *Parser) parseTryExpression() ast.Expression {
+    exp := &ast.TryExpression{Token: p.curToken}
+    p.readToken()
+
+    if !p.curTokenIs(token.LBrace) {
+        p.expectError(token.LBrace)
+        return nil
+    }
+    exp.Body = p.parseBlockStatement()
+
+    if p.skipToken(token.Catch) {
+        if p.curTokenIs(token.Ident) { exp.CatchName, _ = p.parseIdentifier().(*ast.Identifier) }
+
+        if !p.curTokenIs(token.LBrace) {
+            p.expectError(token.LBrace)
+            return nil
+        }
+        exp.Catch = p.parseBlockStatement()
+    }
+
+    if p.skipToken(token.Finally) {
+        if !p.curTokenIs(token.LBrace) {
+            p.expectError(token.LBrace)
+            return nil
+        }
+        exp.Finally = p.parseBlockStatement()
+    }
+
+    if exp.Catch == nil && exp.Finally == nil {
+        p.raiseError(TryWithoutHandlerError)
+        return nil
+    }
+
+    return exp
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+    exp := &ast.MatchExpression{Token: p.curToken, Arms: []*ast.MatchArm{}}
+    p.readToken()
+
+    exp.Subject = p.parseExpression(Lowest)
+    if exp.Subject == nil { return nil }
+    if !p.expectRead(token.LBrace) { return nil }
+
+    for !p.skipToken(token.RBrace) {
+        if p.curTokenIs(token.EOF) {
+            p.raiseError(EOFBeforeMatchEndError)
+            return nil
+        }
+
+        arm := p.parseMatchArm()
+        if arm == nil { return nil }
+
+        exp.Arms = append(exp.Arms, arm)
+
+        // arms with expression bodies must be separated by commas
+        _, isBlock := arm.Body.(*ast.BlockStatement)
+        ended := p.curTokenIs(token.RBrace) || p.curTokenIs(token.EOF)
+        if !p.skipToken(token.Comma) && !isBlock && !ended {
+            p.expectError(token.Comma)
+            return nil
+        }
+    }
+
+    return exp
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+    arm := &ast.MatchArm{Pattern: p.parsePattern()}
+    if arm.Pattern == nil { return nil }
+
+    if p.skipToken(token.If) {
+        arm.Guard = p.parseExpression(Lowest)
+        if arm.Guard == nil { return nil }
+    }
+
+    if !p.expectRead(token.FatArrow) { return nil }
+
+    if p.curTokenIs(token.LBrace) {
+        arm.Body = p.parseBlockStatement()
+        return arm
+    }
+
+    body := &ast.ExpressionStatement{Token: p.curToken, Value: p.parseExpression(Lowest)}
+    if body.Value == nil { return nil }
+
+    arm.Body = body
+    return arm
+}
+
+func (p *Parser) parsePattern() ast.Pattern {
+    switch p.curToken.Type {
+    case token.Ident:
+        if p.curToken.Literal == "_" {
+            wp := &ast.WildcardPattern{Token: p.curToken}
+            p.readToken()
+            return wp
+        }
+
+        tok := p.curToken
+        ident, _ := p.parseIdentifier().(*ast.Identifier)
+        if !isConstructorName(ident.Value) && !p.curTokenIs(token.Dot) && !p.curTokenIs(token.LParen) {
+            return ident
+        }
+        return p.parseVariantPattern(tok, ident)
+    case token.LBracket:
+        return p.parseArrayPattern()
+    case token.Int, token.Float, token.String, token.True, token.False, token.Null:
+        val := p.prefixParseFns[p.curToken.Type]()
+        if val == nil { return nil }
+
+        return &ast.LiteralPattern{Value: val}
+    case token.Minus:
+        val := p.parsePrefixOperator()
+        if val.(*ast.PrefixExpression).Right == nil { return nil } // already reported
+
+        switch val.(*ast.PrefixExpression).Right.(type) {
+        case *ast.IntegerLiteral, *ast.FloatLiteral:
+            return &ast.LiteralPattern{Value: val}
+        }
+        p.raiseError(fmt.Sprintf("%s: %s", InvalidPatternError, val))
+        return nil
+    default:
+        p.raiseError(fmt.Sprintf("%s: %s", InvalidPatternError, p.curToken.Type))
+        return nil
+    }
+}
+
+// parseVariantPattern parses 'Name', 'Name(p, ...)' or a qualified 'a.b.Name(p, ...)'
+func (p *Parser) parseVariantPattern(tok token.Token, ident *ast.Identifier) ast.Pattern {
+    pat := &ast.VariantPattern{Token: tok, Constructor: ident}
+
+    for p.curTokenIs(token.Dot) {
+        pat.Constructor = p.parseMemberExpression(pat.Constructor)
+        if pat.Constructor == nil { return nil }
+    }
+
+    if !p.skipToken(token.LParen) { return pat }
+
+    pat.Args = []ast.Pattern{}
+    for !p.skipToken(token.RParen) {
+        arg := p.parsePattern()
+        if arg == nil { return nil }
+        pat.Args = append(pat.Args, arg)
+
+        if !p.skipToken(token.Comma) && !p.curTokenIs(token.RParen) {
+            p.expectError(token.RParen)
+            return nil
+        }
+    }
+
+    return pat
+}
+
+// identifiers starting with an upper case letter refer to constructors (or constants)
+// in patterns rather than introducing a binding
+func isConstructorName(name string) bool {
+    r, _ := utf8.DecodeRuneInString(name)
+    return unicode.IsUpper(r)
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+    pat := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+    p.readToken()
+
+    for !p.curTokenIs(token.RBracket) {
+        if p.curTokenIs(token.DotDot) {
+            tok := p.curToken
+            p.readToken()
+
+            pat.Rest = &ast.WildcardPattern{Token: tok}
+            if p.curTokenIs(token.Ident) { pat.Rest = p.parsePattern() }
+
+            if !p.curTokenIs(token.RBracket) {
+                p.raiseError(RestPatternPositionError)
+                return nil
+            }
+            break
+        }
+
+        el := p.parsePattern()
+        if el == nil { return nil }
+        pat.Elements = append(pat.Elements, el)
+
+        if !p.skipToken(token.Comma) { break }
+    }
+
+    if !p.expectRead(token.RBracket) { return nil }
+    return pat
+}
+
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
     exp := &ast.InfixExpression{
         Token: p.curToken,
@@ -390,6 +1060,13 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
     return exp
 }
 
+func isIdentifier(name string) bool {
+    l := lexer.New(name)
+    tok := l.NextToken()
+
+    return tok.Type == token.Ident && l.NextToken().Type == token.EOF
+}
+
 func (p *Parser) readToken() { p.curToken = p.lex.NextToken() }
 func (p *Parser) curTokenIs(tt token.TokenType) bool { return p.curToken.Type == tt }
 
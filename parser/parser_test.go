@@ -38,6 +38,90 @@ func TestLetStatement(t *testing.T) {
     }
 }
 
+func TestLetPattern(t *testing.T) {
+    input := "let [a, [b, _], ..rest] = arr;"
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    ls := assertCast[*ast.LetStatement](t, program[0])
+    if ls.Name != nil { t.Fatalf("let statement with pattern has name %s", ls.Name) }
+
+    ap := assertCast[*ast.ArrayPattern](t, ls.Pattern)
+    assertMsg(t, len(ap.Elements), 2, "wrong number of pattern elements")
+    testIdentifier(t, ap.Rest.(*ast.Identifier), "rest")
+    testLiteralExpression(t, ls.Value, "arr")
+    assert(t, ls.String(), "let [a, [b, _], ..rest] = arr;")
+}
+
+func TestConstStatement(t *testing.T) {
+    input := "const x = 5; export const [a, b] = y; { const x = 1; let b = 2; } let z = fn() { const z = 3; };"
+
+    parser, program := runNewParser(t, input, 4)
+    failOnError(t, parser)
+
+    ls := assertCast[*ast.LetStatement](t, program[0])
+    assertToken(t, ls.Token.Literal, "const")
+    assertMsg(t, ls.IsConst(), true, "const statement is not constant")
+    testIdentifier(t, ls.Name, "x")
+    testLiteralExpression(t, ls.Value, 5)
+
+    es := assertCast[*ast.ExportStatement](t, program[1])
+    assert(t, es.String(), "export const [a, b] = y;")
+}
+
+func TestConstRedeclaration(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        {"const x = 1; let x = 2;", "x"},
+        {"const x = 1; const x = 2;", "x"},
+        {"const [a, b] = c; let [b, d] = e;", "b"},
+        {"const P = 1; struct P { x }", "P"},
+        {"const util = 1; import \"util\"", "util"},
+        {"{ const y = 1; let y = 2; }", "y"},
+        {"fn() { const y = 1; let y = 2; }", "y"},
+    }
+
+    for _, tst := range tests {
+        parser := New(lexer.New(tst.input))
+        parser.ParseProgram()
+        assertError(t, parser, ConstRedeclarationError + ": " + tst.expected)
+    }
+}
+
+func TestTypeAnnotations(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        {"let x: int = 5", "let x: int = 5;"},
+        {"const [a, b]: [string] = xs", "const [a, b]: [string] = xs;"},
+        {"let p: Point = q", "let p: Point = q;"},
+        {"let n: null = null", "let n: null = null;"},
+        {"let m: [[float]] = [[1.5]]", "let m: [[float]] = [[1.5]];"},
+        {"fn(a: string, b: [int]) -> bool { true }", "fn(a: string, b: [int]) -> bool {true;};"},
+        {"fn(x: int = 1, ...rest: [int]) { x }", "fn(x: int = 1, ...rest: [int]){x;};"},
+        {"let f: fn(int, int) -> int = add", "let f: fn(int, int) -> int = add;"},
+        {"let g: fn() = h", "let g: fn() = h;"},
+        {"fn(f: fn(int) -> [int]) -> fn() -> int { f }", "fn(f: fn(int) -> [int]) -> fn() -> int {f;};"},
+    }
+
+    for _, tst := range tests {
+        parser, program := runNewParser(t, tst.input, 1)
+        failOnError(t, parser)
+
+        assert(t, program.String(), tst.expected)
+    }
+
+    _, program := runNewParser(t, "let xs: [int] = []", 1)
+    ls := assertCast[*ast.LetStatement](t, program[0])
+    at := assertCast[*ast.ArrayType](t, ls.Type)
+    nt := assertCast[*ast.NamedType](t, at.Element)
+    assert(t, nt.Name, "int")
+}
+
 func TestReturnStatement(t *testing.T) {
     tests := []struct{
         input    string
@@ -58,6 +142,42 @@ func TestReturnStatement(t *testing.T) {
     }
 }
 
+func TestImportStatement(t *testing.T) {
+    tests := []struct{
+        input     string
+        expdPath  string
+        expdName  string
+    }{
+        {`import "util"`, "util", "util"},
+        {`import "lib/strings.lem";`, "lib/strings.lem", "strings"},
+        {`import "lib/my-utils" as utils`, "lib/my-utils", "utils"},
+    }
+
+    for _, tst := range tests {
+        parser, program := runNewParser(t, tst.input, 1)
+        failOnError(t, parser)
+
+        is := assertCast[*ast.ImportStatement](t, program[0])
+        assertToken(t, is.Token.Literal, "import")
+        assertMsg(t, is.Path.Value, tst.expdPath, "incorrect import path")
+        assertMsg(t, is.Name(), tst.expdName, "incorrect module name")
+    }
+}
+
+func TestExportStatement(t *testing.T) {
+    input := "export let x = 5;"
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    es := assertCast[*ast.ExportStatement](t, program[0])
+    assertToken(t, es.Token.Literal, "export")
+
+    ls := assertCast[*ast.LetStatement](t, es.Statement)
+    testIdentifier(t, ls.Name, "x")
+    testLiteralExpression(t, ls.Value, 5)
+}
+
 func TestOperatorPrecedence(t *testing.T) {
     tests := []struct{
         input    string
@@ -86,6 +206,29 @@ func TestOperatorPrecedence(t *testing.T) {
         {"!(true == true)", "(!(true == true));"},
         {"a * [1, 2, 3][b * c] * d", "((a * ([1, 2, 3][(b * c)])) * d);"},
         {"add(a[1], b * a[2], [1, 2][1] * c)", "add((a[1]), (b * (a[2])), (([1, 2][1]) * c));"},
+        {"a[1:2] + a[:b * 2]", "((a[1:2]) + (a[:(b * 2)]));"},
+        {"a[::-1][0]", "((a[::(-1)])[0]);"},
+        {"null", "null;"},
+        {"a ?? b || c", "(a ?? (b || c));"},
+        {"a ?? b ?? c", "((a ?? b) ?? c);"},
+        {"a?[0] ?? 1", "((a?[0]) ?? 1);"},
+        {"a?[1:][0]", "((a?[1:])[0]);"},
+        {"math.abs(x) + a.b[0]", "(math.abs(x) + (a.b[0]));"},
+        {"-a.b", "(-a.b);"},
+        {"p with { x: 1 + 2, y: b } == q", "((p with {x: (1 + 2), y: b}) == q);"},
+        {"f(p) with { x: 1 }.x", "(f(p) with {x: 1}).x;"},
+        {"a.b.c(1.5)", "a.b.c(1.5);"},
+        {"a.map(f).len() + 1", "(a.map(f).len() + 1);"},
+        {"xs |> map(f) |> filter(g)", "filter(map(xs, f), g);"},
+        {"xs |> len", "len(xs);"},
+        {"a ?? b |> f(c)", "f((a ?? b), c);"},
+        {"x |> math.abs", "math.abs(x);"},
+        {"f(x |> g)", "f(g(x));"},
+        {"f(...xs, 1)", "f(...xs, 1);"},
+        {"f(1, y: 2 + 3, z: a[1:])", "f(1, y: (2 + 3), z: (a[1:]));"},
+        {"xs |> f(n: 1)", "f(xs, n: 1);"},
+        {"0..n + 1", "(0 .. (n + 1));"},
+        {"a..=b == c", "((a ..= b) == c);"},
     }
 
     for _, tst := range tests {
@@ -209,6 +352,11 @@ func TestFunctionLiteralParameters(t *testing.T) {
         {input: "fn(){}", expected: []string{}},
         {input: "fn(x){}", expected: []string{"x"}},
         {input: "fn(x, y, z){}", expected: []string{"x", "y", "z"}},
+        {input: "fn([a, b], c){}", expected: []string{"[a, b]", "c"}},
+        {input: "fn([h, ..t], [_, [x]]){}", expected: []string{"[h, ..t]", "[_, [x]]"}},
+        {input: "fn(x, y = 10){}", expected: []string{"x", "y = 10"}},
+        {input: "fn(x = a + 1, ...rest){}", expected: []string{"x = (a + 1)", "...rest"}},
+        {input: "fn(...args){}", expected: []string{"...args"}},
     }
 
     for _, tst := range tests {
@@ -271,6 +419,41 @@ func TestIndexExpression(t *testing.T) {
     testInfixExpression(t, ie.Index, 1, "+", 1)
 }
 
+func TestSliceExpression(t *testing.T) {
+    tests := []struct{
+        input string
+        start any
+        end   any
+        step  any
+    }{
+        {"arr[1:2]", 1, 2, nil},
+        {"arr[1:]", 1, nil, nil},
+        {"arr[:2]", nil, 2, nil},
+        {"arr[:]", nil, nil, nil},
+        {"arr[::3]", nil, nil, 3},
+        {"arr[1:2:3]", 1, 2, 3},
+        {"arr[i:j:]", "i", "j", nil},
+    }
+
+    for _, tst := range tests {
+        parser, program := runNewParser(t, tst.input, 1)
+        failOnError(t, parser)
+
+        stmt := assertCast[*ast.ExpressionStatement](t, program[0])
+        se := assertCast[*ast.SliceExpression](t, stmt.Value)
+        testIdentifier(t, se.Left, "arr")
+
+        bounds := []ast.Expression{se.Start, se.End, se.Step}
+        for i, expd := range []any{tst.start, tst.end, tst.step} {
+            if expd == nil {
+                assertMsg(t, bounds[i], ast.Expression(nil), "slice bound should be empty")
+                continue
+            }
+            testLiteralExpression(t, bounds[i], expd)
+        }
+    }
+}
+
 func TestStringLiteral(t *testing.T) {
     input := `"foo"`;
 
@@ -281,6 +464,158 @@ func TestStringLiteral(t *testing.T) {
     testStringLiteral(t, stmt.Value, "foo")
 }
 
+func TestMemberExpression(t *testing.T) {
+    input := "math.pi"
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    stmt := assertCast[*ast.ExpressionStatement](t, program[0])
+    me := assertCast[*ast.MemberExpression](t, stmt.Value)
+    testIdentifier(t, me.Left, "math")
+    testIdentifier(t, me.Member, "pi")
+}
+
+func TestMatchExpression(t *testing.T) {
+    input := `match xs {
+        [] => 0,
+        [1, _] => -1.5,
+        [x, ..rest] if x > 0 => { x }
+        "a" => null,
+        -2 => 2, _ => 3,
+    }`
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    stmt := assertCast[*ast.ExpressionStatement](t, program[0])
+    me := assertCast[*ast.MatchExpression](t, stmt.Value)
+    testIdentifier(t, me.Subject, "xs")
+    assertMsg(t, len(me.Arms), 6, "wrong number of match arms")
+
+    ap := assertCast[*ast.ArrayPattern](t, me.Arms[2].Pattern)
+    testIdentifier(t, ap.Elements[0].(*ast.Identifier), "x")
+    testIdentifier(t, ap.Rest.(*ast.Identifier), "rest")
+    testInfixExpression(t, me.Arms[2].Guard, "x", ">", 0)
+    assertCast[*ast.BlockStatement](t, me.Arms[2].Body)
+
+    assertCast[*ast.WildcardPattern](t, me.Arms[5].Pattern)
+
+    expected := `match xs {[] => 0, [1, _] => (-1.5), [x, ..rest] if (x > 0) => {x;}, a => null, (-2) => 2, _ => 3}`
+    assert(t, me.String(), expected)
+}
+
+func TestTryExpression(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        {"try { f() } catch e { e.message }", "try {f();} catch e {e.message;}"},
+        {"try { f() } catch { 0 }", "try {f();} catch {0;}"},
+        {"try { f() } finally { g() }", "try {f();} finally {g();}"},
+        {"try { f() } catch e { throw e } finally { g() }", "try {f();} catch e {throw e;} finally {g();}"},
+    }
+
+    for _, tst := range tests {
+        parser, program := runNewParser(t, tst.input, 1)
+        failOnError(t, parser)
+
+        stmt := assertCast[*ast.ExpressionStatement](t, program[0])
+        te := assertCast[*ast.TryExpression](t, stmt.Value)
+        assert(t, te.String(), tst.expected)
+    }
+}
+
+func TestThrowStatement(t *testing.T) {
+    input := `throw "bad input";`
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    ts := assertCast[*ast.ThrowStatement](t, program[0])
+    assertToken(t, ts.Token.Literal, "throw")
+    testStringLiteral(t, ts.Value, "bad input")
+}
+
+func TestStructStatement(t *testing.T) {
+    tests := []struct{
+        input      string
+        expdName   string
+        expdFields []string
+    }{
+        {"struct Point { x, y }", "Point", []string{"x", "y"}},
+        {"struct Empty {}", "Empty", []string{}},
+        {"struct User { name, age, };", "User", []string{"name", "age"}},
+    }
+
+    for _, tst := range tests {
+        parser, program := runNewParser(t, tst.input, 1)
+        failOnError(t, parser)
+
+        ss := assertCast[*ast.StructStatement](t, program[0])
+        testIdentifier(t, ss.Name, tst.expdName)
+        assertMsg(t, len(ss.Fields), len(tst.expdFields), "wrong number of struct fields")
+        for i, f := range tst.expdFields {
+            testIdentifier(t, ss.Fields[i], f)
+        }
+    }
+}
+
+func TestEnumStatement(t *testing.T) {
+    input := "enum Shape { Circle(r), Rect(w, h), Empty, }"
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    es := assertCast[*ast.EnumStatement](t, program[0])
+    testIdentifier(t, es.Name, "Shape")
+    assertMsg(t, len(es.Variants), 3, "wrong number of enum variants")
+
+    testIdentifier(t, es.Variants[1].Name, "Rect")
+    testIdentifier(t, es.Variants[1].Fields[1], "h")
+    assertMsg(t, es.Variants[2].Fields == nil, true, "unit variant has fields")
+    assert(t, es.String(), "enum Shape {Circle(r), Rect(w, h), Empty}")
+}
+
+func TestVariantPattern(t *testing.T) {
+    input := `match s {
+        Shape.Circle(r) => r,
+        Some([x, _]) => x,
+        None => 0,
+        Shape.Rect => 1,
+        Err(_) => 2,
+    }`
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    stmt := assertCast[*ast.ExpressionStatement](t, program[0])
+    me := assertCast[*ast.MatchExpression](t, stmt.Value)
+
+    vp := assertCast[*ast.VariantPattern](t, me.Arms[0].Pattern)
+    assertCast[*ast.MemberExpression](t, vp.Constructor)
+    testIdentifier(t, vp.Args[0].(*ast.Identifier), "r")
+
+    vp = assertCast[*ast.VariantPattern](t, me.Arms[2].Pattern)
+    testIdentifier(t, vp.Constructor, "None")
+    assertMsg(t, vp.Args == nil, true, "bare constructor has arguments")
+
+    expected := `match s {Shape.Circle(r) => r, Some([x, _]) => x, None => 0, Shape.Rect => 1, Err(_) => 2}`
+    assert(t, me.String(), expected)
+}
+
+func TestFloatLiteral(t *testing.T) {
+    input := "2.75;"
+
+    parser, program := runNewParser(t, input, 1)
+    failOnError(t, parser)
+
+    stmt := assertCast[*ast.ExpressionStatement](t, program[0])
+    f := assertCast[*ast.FloatLiteral](t, stmt.Value)
+    assert(t, f.Value, 2.75)
+    assertToken(t, f.Token.Literal, "2.75")
+}
+
 func TestIntegerLiteral(t *testing.T) {
     input := "5;"
 
@@ -308,13 +643,59 @@ func TestErrorCases(t *testing.T) {
     }{
         {"{", EOFBeforeClosingBraceError},
         {"fn(1 + 1){}", NonIdentifierParameterError},
+        {"fn(a, 1){}", NonIdentifierParameterError},
+        {"fn([a, ..b, c]){}", RestPatternPositionError},
+        {"fn(...a, b){}", RestParameterPositionError},
+        {"fn(...[a]){}", NonIdentifierParameterError},
+        {"f(a: 1, 2)", PositionalAfterNamedError},
+        {"try { 1 }", TryWithoutHandlerError},
+        {"struct { x }", "expected Ident, got LBrace"},
+        {"struct P { x y }", "expected RBrace, got Ident"},
+        {"struct P { x, 1 }", NonIdentifierFieldError + ": Int"},
+        {"struct P { x, x }", DuplicateFieldError + ": P.x"},
+        {"enum E { A, A(x) }", DuplicateVariantError + ": E.A"},
+        {"enum E { A, 1 }", NonIdentifierVariantError + ": Int"},
+        {"enum E { A(x, x) }", DuplicateFieldError + ": E.A.x"},
+        {"let x: = 1", InvalidTypeError + ": Assign"},
+        {"let x: [int = 1", "expected RBracket, got Assign"},
+        {"fn(a: 1) {}", InvalidTypeError + ": Int"},
+        {"fn(a) -> {}", InvalidTypeError + ": LBrace"},
+        {"let f: fn(int string) = g", "expected RParen, got Ident"},
+        {"p with { x }", "expected Colon, got RBrace"},
+        {"p with { 1: 2 }", NonIdentifierFieldError + ": Int"},
+        {"try 1 catch { 2 }", "expected LBrace, got Int"},
+        {"try { 1 } catch e 2", "expected LBrace, got Int"},
+        {"let [a, b = [1, 2]", "expected RBracket, got Assign"},
+        {`let "a" = 1`, NonIdentifierAssignmentError},
         {"1a", "illegal token: 1a"},
+        {"a.1", NonIdentifierMemberError},
+        {"import util", "expected String, got Ident"},
+        {`import "my-utils"`, InvalidModuleNameError + `: "my-utils"`},
+        {`import "util" as 1`, "expected Ident, got Int"},
+        {"export 5", NonDeclarationExportError},
+        {"let f = fn() { export let y = 2; y }", NestedExportError},
+        {"if (true) { export const x = 1 }", NestedExportError},
+        {"match 1 { _ => { export let y = 2 } }", NestedExportError},
+        {"x |> 5", InvalidPipeTargetError + ": 5"},
+        {"x |> f(1) + 1", InvalidPipeTargetError + ": (f(1) + 1)"},
+        {"match x { a + 1 => 1 }", "expected FatArrow, got Plus"},
+        {"match x { f(1 + 1) => 1 }", "expected RParen, got Plus"},
+        {"match x { -a => 1 }", InvalidPatternError + ": (-a)"},
+        {"match x { - => 1 }", "no prefix parse function found for 'FatArrow'"},
+        {"match x { 1 => 1 2 => 2 }", "expected Comma, got Int"},
+        {"match x { [..a, b] => 1 }", RestPatternPositionError},
+        {"match x { fn => 1 }", InvalidPatternError + ": Function"},
+        {"match x { 1 => 1", EOFBeforeMatchEndError},
     }
 
     for _, tst := range tests {
         parser, _ := runNewParser(t, tst.input, 0)
         assertError(t, parser, tst.expdError)
     }
+
+    // a pattern whose operand failed to parse is not reported again
+    parser, _ := runNewParser(t, "match x { - => 1 }", 0)
+    assert(t, len(parser.Errors()), 1)
 }
 
 func testInfixExpression(t *testing.T, exp ast.Expression, left any, op string, right any) {
@@ -330,7 +711,7 @@ func testFunctionLiteral(t *testing.T, exp ast.Expression, stmts int, params []s
 
     assertMsg(t, len(f.Parameters), len(params), "wrong number of parameters in function literal")
     for i, ident := range params {
-        testLiteralExpression(t, f.Parameters[i], ident)
+        assertMsg(t, f.Parameters[i].String(), ident, "incorrect function parameter")
     }
 
     assertMsg(t, len(f.Body.Statements), stmts, "wrong number of statements in function body")
@@ -0,0 +1,352 @@
+// Package resolver checks the scoping of a program before it is evaluated, it
+// reports undefined names as errors and unused variables, unused parameters and
+// declarations shadowing builtins as warnings. It also annotates local variables
+// with their (depth, slot) so the evaluator can skip looking them up by name
+package resolver
+
+import (
+    "cmp"
+    "fmt"
+    "slices"
+    "strings"
+
+    "lemur/ast"
+)
+
+const (
+    UndefinedNameError     = "undefined name"
+    UnusedVariableWarning  = "unused variable"
+    UnusedParameterWarning = "unused parameter"
+    ShadowedBuiltinWarning = "shadows builtin"
+)
+
+// Diagnostic is a problem found at the given position of the source, warnings do
+// not prevent a program from running
+type Diagnostic struct {
+    Line    int
+    Col     int
+    Message string
+    Warning bool
+}
+
+func (d *Diagnostic) Error() string { return fmt.Sprintf("%d:%d: %s", d.Line, d.Col, d.Message) }
+
+type bindingKind int
+
+const (
+    variable bindingKind = iota
+    parameter
+    declaration // structs, enums and imports (never reported as unused)
+)
+
+type binding struct {
+    ident *ast.Identifier
+    kind  bindingKind
+    slot  int
+    used  bool
+}
+
+// scope mirrors an object.Environment, a new one is created for every block,
+// function call, match arm and catch clause
+type scope struct {
+    names    map[string]*binding
+    bindings []*binding // every declaration in order, including redeclared names
+    pending  []func()   // function bodies, resolved once the scope is complete
+    outer    *scope
+}
+
+// lookup returns the binding of name and how many scopes out it was declared, the
+// scope is nil if name is not declared
+func (s *scope) lookup(name string) (*binding, int, *scope) {
+    depth := 0
+    for sc := s; sc != nil; sc = sc.outer {
+        if b, ok := sc.names[name]; ok { return b, depth, sc }
+        depth++
+    }
+    return nil, 0, nil
+}
+
+// local reports whether the variables of s are stored in slots, only the root scope
+// (shared by REPL inputs and read by importers) keeps them by name
+func (s *scope) local() bool { return s.outer != nil }
+
+type Resolver struct {
+    builtins map[string]bool
+    prelude  map[string]bool
+
+    scope       *scope
+    diagnostics []*Diagnostic
+}
+
+// New creates a resolver for programs run with the given globals, builtins are
+// looked up before any declared name and prelude names after all of them (as in
+// the evaluator)
+func New(builtins, prelude []string) *Resolver {
+    r := &Resolver{builtins: map[string]bool{}, prelude: map[string]bool{}}
+    for _, name := range builtins { r.builtins[name] = true }
+    for _, name := range prelude { r.prelude[name] = true }
+
+    return r
+}
+
+// Resolve returns the diagnostics for program sorted by position, it annotates the
+// identifiers of program in place
+func (r *Resolver) Resolve(program ast.Program) []*Diagnostic {
+    r.diagnostics = nil
+
+    r.push()
+    r.statements(program)
+    r.pop()
+
+    slices.SortStableFunc(r.diagnostics, func(a, b *Diagnostic) int {
+        return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Col, b.Col))
+    })
+    return r.diagnostics
+}
+
+func (r *Resolver) report(ident *ast.Identifier, warning bool, kind string) {
+    r.diagnostics = append(r.diagnostics, &Diagnostic{
+        Line: ident.Token.Line,
+        Col: ident.Token.Col,
+        Message: kind + ": " + ident.Value,
+        Warning: warning,
+    })
+}
+
+func (r *Resolver) push() { r.scope = &scope{names: map[string]*binding{}, outer: r.scope} }
+
+// pop resolves the functions created in the current scope (they run after the
+// names they refer to have been declared) and reports its unused bindings
+func (r *Resolver) pop() {
+    for len(r.scope.pending) > 0 {
+        fn := r.scope.pending[0]
+        r.scope.pending = r.scope.pending[1:]
+        fn()
+    }
+
+    for _, b := range r.scope.bindings {
+        if b.used || strings.HasPrefix(b.ident.Value, "_") { continue }
+
+        switch b.kind {
+        case variable:
+            r.report(b.ident, true, UnusedVariableWarning)
+        case parameter:
+            r.report(b.ident, true, UnusedParameterWarning)
+        }
+    }
+
+    r.scope = r.scope.outer
+}
+
+func (r *Resolver) declare(ident *ast.Identifier, kind bindingKind) {
+    if r.builtins[ident.Value] || r.prelude[ident.Value] {
+        r.report(ident, true, ShadowedBuiltinWarning)
+    }
+
+    // a redeclared name reuses its slot, like Set replaces the value of a name
+    b := &binding{ident: ident, kind: kind, slot: len(r.scope.names)}
+    if prev, ok := r.scope.names[ident.Value]; ok { b.slot = prev.slot }
+
+    ident.Local, ident.Depth, ident.Slot = r.scope.local(), 0, b.slot
+    r.scope.names[ident.Value] = b
+    r.scope.bindings = append(r.scope.bindings, b)
+}
+
+func (r *Resolver) declarePattern(pat ast.Pattern, kind bindingKind) {
+    for _, ident := range ast.Bindings(pat) {
+        r.declare(ident, kind)
+    }
+}
+
+// use resolves a reference in the same order as the evaluator: builtins, declared
+// names, then the prelude
+func (r *Resolver) use(ident *ast.Identifier) {
+    ident.Local = false
+    if r.builtins[ident.Value] { return }
+
+    if b, depth, sc := r.scope.lookup(ident.Value); sc != nil {
+        b.used = true
+        ident.Local, ident.Depth, ident.Slot = sc.local(), depth, b.slot
+        return
+    }
+    if r.prelude[ident.Value] { return }
+
+    r.report(ident, false, UndefinedNameError)
+}
+
+// known reports whether name would resolve when used here
+func (r *Resolver) known(name string) bool {
+    _, _, sc := r.scope.lookup(name)
+    return r.builtins[name] || sc != nil || r.prelude[name]
+}
+
+func (r *Resolver) statements(stmts []ast.Statement) {
+    for _, stmt := range stmts {
+        r.statement(stmt)
+    }
+}
+
+func (r *Resolver) block(bs *ast.BlockStatement) {
+    if bs == nil { return }
+
+    r.push()
+    r.statements(bs.Statements)
+    r.pop()
+}
+
+func (r *Resolver) statement(stmt ast.Statement) {
+    switch stmt := stmt.(type) {
+    case *ast.ExpressionStatement:
+        r.expression(stmt.Value)
+    case *ast.LetStatement:
+        r.expression(stmt.Value)
+
+        if stmt.Name != nil {
+            r.declare(stmt.Name, variable)
+        } else {
+            r.pattern(stmt.Pattern)
+            r.declarePattern(stmt.Pattern, variable)
+        }
+    case *ast.ReturnStatement:
+        r.expression(stmt.Value)
+    case *ast.ThrowStatement:
+        r.expression(stmt.Value)
+    case *ast.BlockStatement:
+        r.block(stmt)
+    case *ast.StructStatement:
+        r.declare(stmt.Name, declaration)
+    case *ast.EnumStatement:
+        r.declare(stmt.Name, declaration)
+    case *ast.ImportStatement:
+        name := stmt.Alias
+        if name == nil { name = &ast.Identifier{Token: stmt.Token, Value: stmt.Name()} }
+
+        r.declare(name, declaration)
+    case *ast.ExportStatement:
+        r.statement(stmt.Statement)
+
+        // exported names are used by importers
+        for _, name := range ast.DeclaredNames(stmt) {
+            if b, ok := r.scope.names[name]; ok { b.used = true }
+        }
+    }
+}
+
+func (r *Resolver) expression(exp ast.Expression) {
+    switch exp := exp.(type) {
+    case *ast.Identifier:
+        r.use(exp)
+    case *ast.ArrayLiteral:
+        r.expressions(exp.Elements)
+    case *ast.PrefixExpression:
+        r.expression(exp.Right)
+    case *ast.InfixExpression:
+        r.expression(exp.Left)
+        r.expression(exp.Right)
+    case *ast.IndexExpression:
+        r.expression(exp.Left)
+        r.expression(exp.Index)
+    case *ast.SliceExpression:
+        r.expressions([]ast.Expression{exp.Left, exp.Start, exp.End, exp.Step})
+    case *ast.MemberExpression:
+        r.expression(exp.Left)
+    case *ast.ConditionalExpression:
+        r.expression(exp.Condition)
+        r.block(exp.Consequence)
+        r.block(exp.Alternative)
+    case *ast.FunctionLiteral:
+        outer := r.scope
+        outer.pending = append(outer.pending, func() { r.function(exp, outer) })
+    case *ast.CallExpression:
+        r.call(exp)
+    case *ast.SpreadExpression:
+        r.expression(exp.Value)
+    case *ast.NamedArgument:
+        r.expression(exp.Value)
+    case *ast.WithExpression:
+        r.expression(exp.Left)
+        for _, u := range exp.Updates {
+            r.expression(u.Value)
+        }
+    case *ast.MatchExpression:
+        r.expression(exp.Subject)
+
+        for _, arm := range exp.Arms {
+            r.push()
+            r.pattern(arm.Pattern)
+            r.declarePattern(arm.Pattern, variable)
+            r.expression(arm.Guard)
+            r.statement(arm.Body)
+            r.pop()
+        }
+    case *ast.TryExpression:
+        r.block(exp.Body)
+
+        if exp.Catch != nil {
+            r.push()
+            if exp.CatchName != nil { r.declare(exp.CatchName, variable) }
+            r.statements(exp.Catch.Statements)
+            r.pop()
+        }
+        r.block(exp.Finally)
+    }
+}
+
+func (r *Resolver) expressions(exps []ast.Expression) {
+    for _, exp := range exps {
+        if exp != nil { r.expression(exp) }
+    }
+}
+
+// function resolves the parameters and body of fl in a scope enclosed by outer, the
+// scope it was created in
+func (r *Resolver) function(fl *ast.FunctionLiteral, outer *scope) {
+    saved := r.scope
+    r.scope = outer
+    defer func() { r.scope = saved }()
+
+    r.push()
+    for _, p := range fl.Parameters {
+        r.expressions([]ast.Expression{p.Default})
+        r.declarePattern(p.Pattern, parameter)
+    }
+
+    r.statements(fl.Body.Statements)
+    r.pop()
+}
+
+// call resolves a call, methods are looked up like the evaluator does (a builtin or
+// a function in scope) but are not required to exist as they may be members of
+// the receiver
+func (r *Resolver) call(ce *ast.CallExpression) {
+    if me, ok := ce.Function.(*ast.MemberExpression); ok {
+        r.expression(me.Left)
+
+        if b, _, sc := r.scope.lookup(me.Member.Value); sc != nil && !r.builtins[me.Member.Value] { b.used = true }
+    } else {
+        r.expression(ce.Function)
+    }
+
+    r.expressions(ce.Arguments)
+}
+
+// pattern resolves the constructors referenced by variant patterns
+func (r *Resolver) pattern(pat ast.Pattern) {
+    switch pat := pat.(type) {
+    case *ast.ArrayPattern:
+        for _, el := range pat.Elements {
+            r.pattern(el)
+        }
+    case *ast.VariantPattern:
+        // a bare capitalised name is a variant if it refers to something, otherwise
+        // it binds the value like any other name
+        ident, ok := pat.Constructor.(*ast.Identifier)
+        pat.Binding = ok && pat.Args == nil && !r.known(ident.Value)
+        if pat.Binding { return }
+
+        r.expression(pat.Constructor)
+        for _, arg := range pat.Args {
+            r.pattern(arg)
+        }
+    }
+}
@@ -0,0 +1,102 @@
+package resolver
+
+import (
+    "strings"
+    "testing"
+
+    "lemur/lexer"
+    "lemur/parser"
+)
+
+// a subset of the evaluator's globals (the evaluator depends on this package)
+var (
+    testBuiltins = []string{"len", "map", "str"}
+    testPrelude  = []string{"math", "Option", "Result", "Some", "None", "Ok", "Err"}
+)
+
+func TestDiagnostics(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected []string
+    }{
+        {"x", []string{"1:1: " + UndefinedNameError + ": x"}},
+        {"x; let x = 1; x", []string{"1:1: " + UndefinedNameError + ": x"}},
+        {"let x = x", []string{"1:5: " + UnusedVariableWarning + ": x", "1:9: " + UndefinedNameError + ": x"}},
+        {"if (true) { let y = 1; y } else { 2 }; y", []string{"1:40: " + UndefinedNameError + ": y"}},
+        {"let f = fn() { z }; f()", []string{"1:16: " + UndefinedNameError + ": z"}},
+        {"let f = fn(a = b) { a }; f()", []string{"1:16: " + UndefinedNameError + ": b"}},
+        {"match 1 { Foo(x) => x }", []string{"1:11: " + UndefinedNameError + ": Foo"}},
+        {"match 1 { x => x }; x", []string{"1:21: " + UndefinedNameError + ": x"}},
+        {"try { 1 } catch e { e }; e", []string{"1:26: " + UndefinedNameError + ": e"}},
+        {"let x = 1", []string{"1:5: " + UnusedVariableWarning + ": x"}},
+        {"let x = 1; let x = 2; x", []string{"1:5: " + UnusedVariableWarning + ": x"}},
+        {"let [a, ..rest] = [1]; a", []string{"1:11: " + UnusedVariableWarning + ": rest"}},
+        {"{ let inner = 1; }", []string{"1:7: " + UnusedVariableWarning + ": inner"}},
+        {"let f = fn(a, b) { a }; f", []string{"1:15: " + UnusedParameterWarning + ": b"}},
+        {"let f = fn([a, b]) { a }; f", []string{"1:16: " + UnusedParameterWarning + ": b"}},
+        {"let f = fn(...rest) { 1 }; f", []string{"1:15: " + UnusedParameterWarning + ": rest"}},
+        {"match [1] { [x] => 0 }", []string{"1:14: " + UnusedVariableWarning + ": x"}},
+        {"match 1 { N => 0 }", []string{"1:11: " + UnusedVariableWarning + ": N"}},
+        {"match 1 { None => 0 }", []string{}},
+        {"try { 1 } catch e { 0 }", []string{"1:17: " + UnusedVariableWarning + ": e"}},
+        {"let len = fn(x) { x }; len", []string{"1:5: " + ShadowedBuiltinWarning + ": len", "1:5: " + UnusedVariableWarning + ": len"}},
+        // builtins are looked up first so the parameter is never used
+        {"let f = fn(map) { map }; f", []string{"1:12: " + ShadowedBuiltinWarning + ": map", "1:12: " + UnusedParameterWarning + ": map"}},
+        {"let math = 1; math", []string{"1:5: " + ShadowedBuiltinWarning + ": math"}},
+        {"enum Option { Some(v) }", []string{"1:6: " + ShadowedBuiltinWarning + ": Option"}},
+    }
+
+    for i, tst := range tests {
+        diags := runResolve(t, tst.input)
+
+        if len(diags) != len(tst.expected) {
+            t.Errorf("test %d: expected %d diagnostics, got %d %v", i + 1, len(tst.expected), len(diags), diags)
+            continue
+        }
+        for j, d := range diags {
+            assert(t, i, d.Error(), tst.expected[j])
+            assert(t, i, d.Warning, !strings.Contains(tst.expected[j], UndefinedNameError))
+        }
+    }
+}
+
+func TestResolved(t *testing.T) {
+    tests := []string{
+        // functions run after the names declared later in their scope
+        "let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(4)",
+        "let fib = fn(n) { if (n < 2) { return n } fib(n - 1) + fib(n - 2) }; fib(10)",
+        "let f = fn(x, y = x + 1) { y }; f(1)",
+        "let add = fn(a: int, b: int) -> int { a + b }; [1, 2].add(3)",
+        "len([1]) + math.sqrt(4) + str(1).len()",
+        "Some(1) ?? None ?? Ok(2) ?? Option.Some(3)",
+        "struct P { x }; enum E { A(v) }; match E.A(P(1)) { E.A(p) => p.x }",
+        "import \"util\"; util.f()",
+        "export let x = 1; export struct S { a }",
+        "let _unused = 1; let f = fn(_x) { 0 }; f(1)",
+        "let x = 1; let g = fn() { x }; g() with { a: 1 }",
+        "let xs = [1, 2]; xs[0] + xs[1:][0] + len(...xs)",
+        "let f = fn(n) { n }; f(n: 1)",
+        "let p = fn(x) { x }; 1 |> p",
+        "try { throw 1 } catch e { e.value } finally { 0 }",
+    }
+
+    for i, input := range tests {
+        for _, d := range runResolve(t, input) {
+            t.Errorf("test %d: unexpected diagnostic %s", i + 1, d)
+        }
+    }
+}
+
+func runResolve(t *testing.T, input string) []*Diagnostic {
+    p := parser.New(lexer.New(input))
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0 { t.Fatalf("failed to parse %q: %s", input, p.Errors()[0]) }
+
+    return New(testBuiltins, testPrelude).Resolve(program)
+}
+
+func assert(t *testing.T, testIdx int, val, expected any) {
+    if val != expected {
+        t.Errorf("test %d: incorrect value, expected %v (got %v)", testIdx + 1, expected, val)
+    }
+}
@@ -6,6 +6,8 @@ type TokenType int
 type Token struct {
     Type TokenType
     Literal string
+    Line int
+    Col int
 }
 
 const (
@@ -16,10 +18,16 @@ const (
     Ident
     String
     Int
+    Float
 
     // Delimiters
     Comma
     Semicolon
+    Colon
+    Dot
+    DotDot
+    DotDotEq
+    Ellipsis
     LParen
     RParen
     LBrace
@@ -41,15 +49,33 @@ const (
     NotEq
     And
     Or
+    NullCoalesce
+    OptionalIndex
+    Pipe
+    FatArrow
+    Arrow
 
     // Keywords
     Function
     Let
+    Const
     True
     False
+    Null
     If
     Else
     Return
+    Import
+    Export
+    As
+    Match
+    Try
+    Catch
+    Finally
+    Throw
+    Struct
+    With
+    Enum
 )
 
 var Operators = map[string]TokenType{
@@ -65,16 +91,37 @@ var Operators = map[string]TokenType{
     "!=": NotEq,
     "&&": And,
     "||": Or,
+    "??": NullCoalesce,
+    "?[": OptionalIndex,
+    "|>": Pipe,
+    "=>": FatArrow,
+    "->": Arrow,
+    "..": DotDot,
+    "..=": DotDotEq,
+    "...": Ellipsis,
 }
 
 var Keywords = map[string]TokenType{ // can this be a bi-directional map?
     "fn": Function,
     "let": Let,
+    "const": Const,
     "true": True,
     "false": False,
+    "null": Null,
     "if": If,
     "else": Else,
     "return": Return,
+    "import": Import,
+    "export": Export,
+    "as": As,
+    "match": Match,
+    "try": Try,
+    "catch": Catch,
+    "finally": Finally,
+    "throw": Throw,
+    "struct": Struct,
+    "with": With,
+    "enum": Enum,
 }
 
 func OperatorType(op string) TokenType {
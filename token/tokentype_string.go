@@ -13,38 +13,62 @@ func _() {
 	_ = x[Ident-2]
 	_ = x[String-3]
 	_ = x[Int-4]
-	_ = x[Comma-5]
-	_ = x[Semicolon-6]
-	_ = x[LParen-7]
-	_ = x[RParen-8]
-	_ = x[LBrace-9]
-	_ = x[RBrace-10]
-	_ = x[LBracket-11]
-	_ = x[RBracket-12]
-	_ = x[Assign-13]
-	_ = x[Plus-14]
-	_ = x[Minus-15]
-	_ = x[Bang-16]
-	_ = x[Asterisk-17]
-	_ = x[Slash-18]
-	_ = x[LT-19]
-	_ = x[GT-20]
-	_ = x[Eq-21]
-	_ = x[NotEq-22]
-	_ = x[And-23]
-	_ = x[Or-24]
-	_ = x[Function-25]
-	_ = x[Let-26]
-	_ = x[True-27]
-	_ = x[False-28]
-	_ = x[If-29]
-	_ = x[Else-30]
-	_ = x[Return-31]
+	_ = x[Float-5]
+	_ = x[Comma-6]
+	_ = x[Semicolon-7]
+	_ = x[Colon-8]
+	_ = x[Dot-9]
+	_ = x[DotDot-10]
+	_ = x[DotDotEq-11]
+	_ = x[Ellipsis-12]
+	_ = x[LParen-13]
+	_ = x[RParen-14]
+	_ = x[LBrace-15]
+	_ = x[RBrace-16]
+	_ = x[LBracket-17]
+	_ = x[RBracket-18]
+	_ = x[Assign-19]
+	_ = x[Plus-20]
+	_ = x[Minus-21]
+	_ = x[Bang-22]
+	_ = x[Asterisk-23]
+	_ = x[Slash-24]
+	_ = x[LT-25]
+	_ = x[GT-26]
+	_ = x[Eq-27]
+	_ = x[NotEq-28]
+	_ = x[And-29]
+	_ = x[Or-30]
+	_ = x[NullCoalesce-31]
+	_ = x[OptionalIndex-32]
+	_ = x[Pipe-33]
+	_ = x[FatArrow-34]
+	_ = x[Arrow-35]
+	_ = x[Function-36]
+	_ = x[Let-37]
+	_ = x[Const-38]
+	_ = x[True-39]
+	_ = x[False-40]
+	_ = x[Null-41]
+	_ = x[If-42]
+	_ = x[Else-43]
+	_ = x[Return-44]
+	_ = x[Import-45]
+	_ = x[Export-46]
+	_ = x[As-47]
+	_ = x[Match-48]
+	_ = x[Try-49]
+	_ = x[Catch-50]
+	_ = x[Finally-51]
+	_ = x[Throw-52]
+	_ = x[Struct-53]
+	_ = x[With-54]
+	_ = x[Enum-55]
 }
 
-const _TokenType_name = "IllegalEOFIdentStringIntCommaSemicolonLParenRParenLBraceRBraceLBracketRBracketAssignPlusMinusBangAsteriskSlashLTGTEqNotEqAndOrFunctionLetTrueFalseIfElseReturn"
+const _TokenType_name = "IllegalEOFIdentStringIntFloatCommaSemicolonColonDotDotDotDotDotEqEllipsisLParenRParenLBraceRBraceLBracketRBracketAssignPlusMinusBangAsteriskSlashLTGTEqNotEqAndOrNullCoalesceOptionalIndexPipeFatArrowArrowFunctionLetConstTrueFalseNullIfElseReturnImportExportAsMatchTryCatchFinallyThrowStructWithEnum"
 
-var _TokenType_index = [...]uint8{0, 7, 10, 15, 21, 24, 29, 38, 44, 50, 56, 62, 70, 78, 84, 88, 93, 97, 105, 110, 112, 114, 116, 121, 124, 126, 134, 137, 141, 146, 148, 152, 158}
+var _TokenType_index = [...]uint16{0, 7, 10, 15, 21, 24, 29, 34, 43, 48, 51, 57, 65, 73, 79, 85, 91, 97, 105, 113, 119, 123, 128, 132, 140, 145, 147, 149, 151, 156, 159, 161, 173, 186, 190, 198, 203, 211, 214, 219, 223, 228, 232, 234, 238, 244, 250, 256, 258, 263, 266, 271, 278, 283, 289, 293, 297}
 
 func (i TokenType) String() string {
 	idx := int(i) - 0
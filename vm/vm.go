@@ -0,0 +1,709 @@
+// Package vm runs bytecode produced by the compiler, every instruction mirrors the
+// evaluator so that both produce the same values, errors and error positions
+package vm
+
+import (
+    "errors"
+    "fmt"
+    "maps"
+    "slices"
+
+    "lemur/ast"
+    "lemur/compiler"
+    "lemur/eval"
+    "lemur/object"
+)
+
+const StackSize = 2048 // initial size, the stack grows as needed
+
+// Closure is a compiled function with the environment it was created in, it has the
+// type of an evaluated function so that builtins accept it as a callback
+type Closure struct {
+    Fn        *compiler.Function
+    Env       *object.Environment
+    Constants []object.Object
+    Name      string
+}
+
+func (c *Closure) Type() object.ObjectType { return object.FunctionType }
+func (c *Closure) String() string { return c.Fn.Source }
+
+// spread holds the elements of a spread argument until the call collects them
+type spread []object.Object
+
+func (s spread) Type() object.ObjectType { return "Spread" }
+func (s spread) String() string { return "..." }
+
+// frame is a running function, pc is the start of the current instruction and bp
+// the height the stack is restored to when the frame is left
+type frame struct {
+    cl    *Closure
+    ip    int
+    pc    int
+    bp    int
+    env   *object.Environment
+    args  []object.Object
+    named map[string]object.Object
+    block bool
+}
+
+type VM struct {
+    stack  []object.Object
+    sp     int
+    frames []*frame
+    fp     int
+}
+
+var modules *eval.ModuleLoader
+
+func init() { modules = eval.NewModuleLoader(Run) }
+
+func New() *VM { return &VM{stack: make([]object.Object, StackSize)} }
+
+// Run compiles and runs program in env, it is a drop-in replacement for eval.Eval
+func Run(program ast.Program, env *object.Environment) object.Object {
+    bc, err := compiler.Compile(program)
+    if errors.Is(err, compiler.ErrTooLarge) { return &object.Error{Kind: compiler.ProgramTooLargeError, Message: err.Error()} }
+    if err != nil { return createError(eval.UnknownASTNodeError + eval.InternalErrorPostfix, "%s", err) }
+
+    return New().Run(bc, env)
+}
+
+func (vm *VM) Run(bc *compiler.Bytecode, env *object.Environment) object.Object {
+    vm.pushFrame(&Closure{Fn: bc.Main, Env: env, Constants: bc.Constants}, env, nil, nil, true, vm.sp)
+    return vm.run(vm.fp - 1)
+}
+
+func (vm *VM) push(obj object.Object) {
+    if vm.sp == len(vm.stack) { vm.stack = append(vm.stack, make([]object.Object, len(vm.stack))...) }
+
+    vm.stack[vm.sp] = obj
+    vm.sp++
+}
+
+func (vm *VM) pop() object.Object {
+    vm.sp--
+    return vm.stack[vm.sp]
+}
+
+// popN removes the top n values, the returned slice is only valid until the next push
+func (vm *VM) popN(n int) []object.Object {
+    vm.sp -= n
+    return vm.stack[vm.sp:vm.sp + n]
+}
+
+func (vm *VM) pushFrame(cl *Closure, env *object.Environment, args []object.Object, named map[string]object.Object, block bool, bp int) {
+    if vm.fp == len(vm.frames) { vm.frames = append(vm.frames, &frame{}) }
+
+    *vm.frames[vm.fp] = frame{cl: cl, bp: bp, env: env, args: args, named: named, block: block}
+    vm.fp++
+}
+
+func (vm *VM) current() (*frame, compiler.Instructions, []object.Object) {
+    f := vm.frames[vm.fp - 1]
+    return f, f.cl.Fn.Instructions, f.cl.Constants
+}
+
+func read2(ins compiler.Instructions, ip int) int { return int(ins[ip]) << 8 | int(ins[ip + 1]) }
+
+// run executes frames until the frame at base returns, calls to closures are run in
+// the same loop while calls from builtins and try blocks run nested
+func (vm *VM) run(base int) object.Object {
+    f, ins, consts := vm.current()
+
+    for {
+        f.pc = f.ip
+        op := compiler.Opcode(ins[f.ip])
+        f.ip++
+
+        var err, ret object.Object
+
+        switch op {
+        case compiler.OpConstant:
+            vm.push(consts[read2(ins, f.ip)])
+            f.ip += 2
+        case compiler.OpNull:
+            vm.push(eval.Null)
+        case compiler.OpTrue:
+            vm.push(eval.True)
+        case compiler.OpFalse:
+            vm.push(eval.False)
+        case compiler.OpPop:
+            vm.sp--
+        case compiler.OpDup:
+            vm.push(vm.stack[vm.sp - 1])
+        case compiler.OpSwap:
+            vm.stack[vm.sp - 1], vm.stack[vm.sp - 2] = vm.stack[vm.sp - 2], vm.stack[vm.sp - 1]
+
+        case compiler.OpGetName:
+            name := consts[read2(ins, f.ip)].String()
+            f.ip += 2
+
+            obj, ok := lookup(name, f.env)
+            if ok { vm.push(obj) } else { err = obj }
+        case compiler.OpGetLocal:
+            depth, slot, name := read2(ins, f.ip), read2(ins, f.ip + 2), read2(ins, f.ip + 4)
+            f.ip += 6
+
+            obj, ok := f.env.GetAt(depth, slot, consts[name].String())
+            if !ok { obj, ok = lookup(consts[name].String(), f.env) }
+            if ok { vm.push(obj) } else { err = obj }
+        case compiler.OpDefine:
+            f.env.Set(consts[read2(ins, f.ip)].String(), vm.pop())
+            f.ip += 2
+        case compiler.OpDefineLocal:
+            slot, name := read2(ins, f.ip), read2(ins, f.ip + 2)
+            f.ip += 4
+
+            f.env.SetAt(slot, consts[name].String(), vm.pop())
+        case compiler.OpNameFunction:
+            if cl, ok := vm.stack[vm.sp - 1].(*Closure); ok && cl.Name == "" {
+                cl.Name = consts[read2(ins, f.ip)].String()
+            }
+            f.ip += 2
+        case compiler.OpCheckConst:
+            names := consts[read2(ins, f.ip)].(*object.Array)
+            f.ip += 2
+
+            for _, name := range names.Elements {
+                if f.env.IsConst(name.String()) {
+                    err = createError(eval.ConstReassignmentError, "%s", name)
+                    break
+                }
+            }
+        case compiler.OpFreeze:
+            vm.stack[vm.sp - 1] = object.Freeze(vm.stack[vm.sp - 1])
+        case compiler.OpMarkConst:
+            names := consts[read2(ins, f.ip)].(*object.Array)
+            f.ip += 2
+
+            for _, name := range names.Elements { f.env.MarkConst(name.String()) }
+        case compiler.OpPushScope:
+            f.env = object.CreateEnclosedEnvironment(f.env)
+        case compiler.OpPopScope:
+            f.env = f.env.Outer()
+
+        case compiler.OpJump:
+            f.ip = read2(ins, f.ip)
+        case compiler.OpJumpIfFalse:
+            if vm.pop() == eval.False { f.ip = read2(ins, f.ip) } else { f.ip += 2 }
+        case compiler.OpJumpIfNull:
+            if vm.stack[vm.sp - 1] == eval.Null { f.ip = read2(ins, f.ip) } else { f.ip += 2 }
+        case compiler.OpJumpIfNotNull:
+            if vm.stack[vm.sp - 1] != eval.Null {
+                f.ip = read2(ins, f.ip)
+            } else {
+                vm.sp--
+                f.ip += 2
+            }
+        case compiler.OpBranch:
+            switch vm.pop() {
+            case eval.True:
+                f.ip += 4
+            case eval.False:
+                f.ip = read2(ins, f.ip)
+            default:
+                err = createError(eval.InvalidConditionError, "%s", consts[read2(ins, f.ip + 2)])
+            }
+        case compiler.OpGuard:
+            cond := vm.pop()
+            switch {
+            case cond.Type() != object.BooleanType:
+                err = createError(eval.InvalidConditionError, "%s", consts[read2(ins, f.ip + 2)])
+            case cond == eval.False:
+                f.ip = read2(ins, f.ip)
+            default:
+                f.ip += 4
+            }
+        case compiler.OpReturn:
+            ret = vm.pop()
+            if f.block { ret = &object.Return{Value: ret} }
+        case compiler.OpEnd:
+            val := vm.pop()
+            vm.sp = f.bp
+            vm.fp--
+            return val
+        case compiler.OpTry:
+            body, catch, finally := read2(ins, f.ip), read2(ins, f.ip + 2), read2(ins, f.ip + 4)
+            f.ip += 6
+
+            switch res := vm.try(f, body, catch, finally).(type) {
+            case *object.Error:
+                err = res
+            case *object.Return:
+                ret = res
+                if !f.block { ret = res.Value }
+            default:
+                vm.push(res)
+            }
+        case compiler.OpThrow:
+            err = eval.Throw(vm.pop())
+        case compiler.OpRaise:
+            e := *consts[read2(ins, f.ip)].(*object.Error)
+            f.ip += 2
+            err = &e
+
+        case compiler.OpInfix:
+            operator := compiler.Operators[ins[f.ip]]
+            f.ip++
+
+            right := vm.pop()
+            res := infix(operator, vm.pop(), right)
+            if res.Type() == object.ErrorType { err = res } else { vm.push(res) }
+        case compiler.OpPrefix:
+            res := eval.Prefix(compiler.Operators[ins[f.ip]], vm.pop())
+            f.ip++
+
+            if res.Type() == object.ErrorType { err = res } else { vm.push(res) }
+        case compiler.OpEqual:
+            lit := vm.pop()
+            if object.Equal(lit, vm.pop()) { vm.push(eval.True) } else { vm.push(eval.False) }
+        case compiler.OpArray:
+            n := read2(ins, f.ip)
+            f.ip += 2
+
+            vm.push(&object.Array{Elements: slices.Clone(vm.popN(n))})
+        case compiler.OpIndex:
+            index := vm.pop()
+            res := eval.Index(vm.pop(), index)
+            if res.Type() == object.ErrorType { err = res } else { vm.push(res) }
+        case compiler.OpSliceBound:
+            k := int(ins[f.ip])
+            f.ip++
+
+            if _, e := eval.SliceBound(vm.stack[vm.sp - 2 - k], vm.stack[vm.sp - 1]); e != nil { err = e }
+        case compiler.OpSlice:
+            mask := int(ins[f.ip])
+            f.ip++
+
+            var bounds [3]*int64
+            for i := 2; i >= 0; i-- {
+                if mask & (1 << i) == 0 { continue }
+
+                n := vm.pop().(*object.Integer).Value
+                bounds[i] = &n
+            }
+
+            res := eval.Slice(vm.pop(), bounds)
+            if res.Type() == object.ErrorType { err = res } else { vm.push(res) }
+        case compiler.OpMember:
+            res := eval.Member(vm.pop(), consts[read2(ins, f.ip)].String())
+            f.ip += 2
+
+            if res.Type() == object.ErrorType { err = res } else { vm.push(res) }
+        case compiler.OpWithCheck:
+            if left := vm.stack[vm.sp - 1]; left.Type() != object.RecordType {
+                err = createError(eval.InvalidUpdateError, "%s with {...}", left.Type())
+            }
+        case compiler.OpWithField:
+            i, name := read2(ins, f.ip), consts[read2(ins, f.ip + 2)].String()
+            f.ip += 4
+
+            rec := vm.stack[vm.sp - 1 - i].(*object.Record)
+            if _, ok := rec.Def.FieldIndex(name); !ok {
+                err = createError(eval.MemberNotFoundError, "%s.%s", rec.Def.Name, name)
+            }
+        case compiler.OpWith:
+            names := consts[read2(ins, f.ip)].(*object.Array).Elements
+            f.ip += 2
+
+            updates := vm.popN(len(names))
+            rec := vm.pop().(*object.Record)
+
+            values := slices.Clone(rec.Values)
+            for i, name := range names {
+                j, _ := rec.Def.FieldIndex(name.String())
+                values[j] = updates[i]
+            }
+            vm.push(&object.Record{Def: rec.Def, Values: values})
+
+        case compiler.OpCall:
+            n := int(ins[f.ip])
+            f.ip++
+
+            // the arguments of a closure stay on the stack below its frame
+            if cl, ok := vm.stack[vm.sp - n - 1].(*Closure); ok {
+                err = vm.enter(cl, vm.stack[vm.sp - n:vm.sp], nil, vm.sp - n - 1)
+                break
+            }
+
+            args := slices.Clone(vm.popN(n))
+            err = vm.call(vm.pop(), args, nil)
+        case compiler.OpCallArgs:
+            n, kinds := int(ins[f.ip]), read2(ins, f.ip + 1)
+            f.ip += 3
+
+            args, named := collectArguments(vm.popN(n), consts[kinds])
+            err = vm.call(vm.pop(), args, named)
+        case compiler.OpCallMethod:
+            name, n, kinds := consts[read2(ins, f.ip)].String(), int(ins[f.ip + 2]), read2(ins, f.ip + 3)
+            f.ip += 5
+
+            var args []object.Object
+            var named map[string]object.Object
+            if kinds == compiler.NoOperand {
+                args = slices.Clone(vm.popN(n))
+            } else {
+                args, named = collectArguments(vm.popN(n), consts[kinds])
+            }
+
+            err = vm.callMethod(vm.pop(), name, args, named, f.env)
+        case compiler.OpSpread:
+            obj := vm.pop()
+            if seq, ok := obj.(object.Iterable); !ok {
+                err = createError(eval.InvalidArgumentError, "cannot spread %s", obj.Type())
+            } else if elems, e := eval.CollectIterable(seq); e != nil {
+                err = e
+            } else {
+                vm.push(spread(elems))
+            }
+        case compiler.OpParam:
+            i, bind := int(ins[f.ip]), read2(ins, f.ip + 1)
+            f.ip += 3
+
+            var namedVal object.Object
+            isNamed, name := false, f.cl.Fn.Params[i].Name
+            if f.named != nil { namedVal, isNamed = f.named[name] }
+
+            switch {
+            case i < len(f.args):
+                if isNamed {
+                    err = createError(eval.InvalidArgumentError, "%s got more than one value for %s", functionName(f.cl), name)
+                    break
+                }
+                vm.push(f.args[i])
+                f.ip = bind
+            case isNamed:
+                vm.push(namedVal)
+                f.ip = bind
+            }
+        case compiler.OpRest:
+            n := int(ins[f.ip])
+            f.ip++
+
+            extra := []object.Object{}
+            if len(f.args) > n { extra = slices.Clone(f.args[n:]) }
+            vm.push(&object.Array{Elements: extra})
+        case compiler.OpArityError:
+            err = arityError(f.cl, len(f.args) + len(f.named))
+        case compiler.OpClosure:
+            fn := consts[read2(ins, f.ip)].(*compiler.Function)
+            f.ip += 2
+
+            vm.push(&Closure{Fn: fn, Env: f.env, Constants: consts})
+
+        case compiler.OpStruct:
+            vm.push(consts[read2(ins, f.ip)])
+            f.ip += 2
+        case compiler.OpEnum:
+            tmpl := consts[read2(ins, f.ip)].(*object.Enum)
+            f.ip += 2
+
+            // each declaration creates a distinct enum, as variants are compared by identity
+            enum := object.NewEnum(tmpl.Name)
+            for _, name := range tmpl.Variants {
+                var fields []string
+                if def, ok := tmpl.Members[name].(*object.Struct); ok { fields = def.Fields }
+
+                enum.AddVariant(name, fields)
+            }
+            vm.push(enum)
+        case compiler.OpImport:
+            path := eval.ResolveModulePath(consts[read2(ins, f.ip)].String(), f.env.Path())
+            f.ip += 2
+
+            mod := modules.Load(path)
+            if mod.Type() == object.ErrorType { err = mod } else { vm.push(mod) }
+
+        case compiler.OpMatchArray:
+            n, rest := read2(ins, f.ip), ins[f.ip + 2] == 1
+            f.ip += 3
+
+            arr, ok := vm.pop().(*object.Array)
+            if !ok || len(arr.Elements) < n || !rest && len(arr.Elements) != n {
+                vm.push(eval.False)
+                break
+            }
+
+            if rest { vm.push(arr.Slice(n, len(arr.Elements))) }
+            for i := n - 1; i >= 0; i-- {
+                vm.push(arr.Elements[i])
+            }
+            vm.push(eval.True)
+        case compiler.OpMatchVariant:
+            nargs, name := read2(ins, f.ip), consts[read2(ins, f.ip + 2)]
+            f.ip += 4
+
+            err = vm.matchVariant(nargs, name)
+        case compiler.OpMismatch:
+            name := consts[read2(ins, f.ip)]
+            err = createError(eval.PatternMismatchError, "%s = %s", name, vm.stack[vm.sp - 1])
+        case compiler.OpNoMatch:
+            err = createError(eval.NoMatchError, "%s", vm.stack[vm.sp - 1])
+
+        default:
+            def, e := compiler.Lookup(op)
+            if e != nil { return createError(eval.NotYetImplementedError + eval.InternalErrorPostfix, "%s", e) }
+            return createError(eval.NotYetImplementedError + eval.InternalErrorPostfix, "%s", def.Name)
+        }
+
+        switch {
+        case err != nil:
+            e := err.(*object.Error)
+            for {
+                e = tag(e, f)
+
+                vm.sp = f.bp
+                vm.fp--
+                if vm.fp == base { return e }
+                f, ins, consts = vm.current()
+            }
+        case ret != nil:
+            vm.sp = f.bp
+            vm.fp--
+            if vm.fp == base { return ret }
+
+            f, ins, consts = vm.current()
+            vm.push(ret)
+        case vm.frames[vm.fp - 1] != f:
+            // a call entered a closure
+            f, ins, consts = vm.current()
+        }
+    }
+}
+
+// tag positions e at the instruction f is running, unless it has a position
+func tag(e *object.Error, f *frame) *object.Error {
+    line, col := f.cl.Fn.Position(f.pc)
+    return e.At(line, col)
+}
+
+// lookup finds a name which is not a builtin, its error is returned as the object
+func lookup(name string, env *object.Environment) (object.Object, bool) {
+    if obj, ok := env.Get(name); ok { return obj, true }
+    if obj, ok := eval.LookupPrelude(name); ok { return obj, true }
+
+    return createError(eval.IdentifierNotFoundError, "%s", name), false
+}
+
+func infix(operator string, left, right object.Object) object.Object {
+    l, ok := left.(*object.Integer)
+    if !ok { return eval.Infix(operator, left, right) }
+    r, ok := right.(*object.Integer)
+    if !ok { return eval.Infix(operator, left, right) }
+
+    switch operator {
+    case "+":
+        return &object.Integer{Value: l.Value + r.Value}
+    case "-":
+        return &object.Integer{Value: l.Value - r.Value}
+    case "*":
+        return &object.Integer{Value: l.Value * r.Value}
+    case "<":
+        return boolean(l.Value < r.Value)
+    case ">":
+        return boolean(l.Value > r.Value)
+    case "==":
+        return boolean(l.Value == r.Value)
+    case "!=":
+        return boolean(l.Value != r.Value)
+    default:
+        return eval.Infix(operator, left, right)
+    }
+}
+
+func boolean(val bool) object.Object {
+    if val { return eval.True }
+    return eval.False
+}
+
+// collectArguments splits the arguments of a call by the kinds the compiler recorded
+// for them: positional, spread or the name of a named argument
+func collectArguments(vals []object.Object, kinds object.Object) ([]object.Object, map[string]object.Object) {
+    args := make([]object.Object, 0, len(vals))
+    var named map[string]object.Object
+
+    for i, kind := range kinds.(*object.Array).Elements {
+        switch kind.String() {
+        case "":
+            args = append(args, vals[i])
+        case compiler.SpreadArg:
+            args = append(args, vals[i].(spread)...)
+        default:
+            if named == nil { named = map[string]object.Object{} }
+            named[kind.String()] = vals[i]
+        }
+    }
+
+    return args, named
+}
+
+// call calls fn like the evaluator's callFunction, a closure is entered rather than
+// run so its frame is run by the caller
+func (vm *VM) call(fn object.Object, args []object.Object, named map[string]object.Object) object.Object {
+    if cl, ok := fn.(*Closure); ok { return vm.enter(cl, args, named, vm.sp) }
+
+    res := vm.callNative(fn, args, named)
+    if res.Type() == object.ErrorType { return res }
+
+    vm.push(res)
+    return nil
+}
+
+func (vm *VM) callNative(fn object.Object, args []object.Object, named map[string]object.Object) object.Object {
+    switch fn := fn.(type) {
+    case *object.Struct:
+        return eval.ConstructRecord(fn, args, named)
+    case *object.Builtin:
+        if len(named) == 0 { return fn.Fn(vm.apply, args...) }
+    }
+
+    if len(named) != 0 { return createError(eval.InvalidArgumentError, "%s does not accept named arguments", fn.Type()) }
+
+    return createError(eval.InvalidCastError + eval.InternalErrorPostfix, "%T cannot be cast to object.Function", fn)
+}
+
+// apply is the applier given to builtins, it runs closures to completion
+func (vm *VM) apply(fn object.Object, args ...object.Object) object.Object {
+    cl, ok := fn.(*Closure)
+    if !ok { return vm.callNative(fn, args, nil) }
+
+    if err := vm.enter(cl, args, nil, vm.sp); err != nil { return err }
+    return vm.run(vm.fp - 1)
+}
+
+// callMethod mirrors the evaluator's method calls: members of namespaces, modules and
+// enums are called directly, otherwise recv.f(args) calls f(recv, args)
+func (vm *VM) callMethod(recv object.Object, name string, args []object.Object, named map[string]object.Object, env *object.Environment) object.Object {
+    switch recv := recv.(type) {
+    case *object.Namespace, *object.Module, *object.Enum:
+        fn := eval.Member(recv, name)
+        if fn.Type() == object.ErrorType { return fn }
+
+        return vm.call(fn, args, named)
+    case *object.Record:
+        // a callable field takes precedence over a function of the same name
+        if field, ok := recv.Get(name); ok && eval.IsCallable(field) { return vm.call(field, args, named) }
+    }
+
+    fn, ok := eval.LookupBuiltin(name)
+    if !ok {
+        fn, ok = env.Get(name)
+        ok = ok && eval.IsCallable(fn)
+    }
+    if !ok { return createError(eval.MethodNotFoundError, "%s.%s", recv.Type(), name) }
+
+    return vm.call(fn, append([]object.Object{recv}, args...), named)
+}
+
+// enter checks the arguments which do not depend on the parameters being bound and
+// pushes the frame of cl, its prologue binds the parameters
+func (vm *VM) enter(cl *Closure, args []object.Object, named map[string]object.Object, bp int) object.Object {
+    params := cl.Fn.Params
+    n := len(params)
+    if n > 0 && params[n - 1].Rest { n-- }
+
+    if len(args) > n && n == len(params) { return arityError(cl, len(args) + len(named)) }
+
+    for _, name := range sortedKeys(named) {
+        if !slices.ContainsFunc(params[:n], func(p compiler.Param) bool { return p.Name == name }) {
+            return createError(eval.InvalidArgumentError, "%s has no parameter %s", functionName(cl), name)
+        }
+    }
+
+    vm.pushFrame(cl, object.CreateEnclosedEnvironment(cl.Env), args, named, false, bp)
+    return nil
+}
+
+// try runs the blocks of a try expression like the evaluator, finally only replaces
+// the result if it fails or returns
+func (vm *VM) try(f *frame, body, catch, finally int) object.Object {
+    res := vm.runBlock(f, body, nil)
+
+    if err, ok := res.(*object.Error); ok && catch != compiler.NoOperand {
+        res = vm.runBlock(f, catch, &object.Exception{Err: err})
+    }
+
+    if finally != compiler.NoOperand {
+        fin := vm.runBlock(f, finally, nil)
+        if fin.Type() == object.ErrorType || fin.Type() == object.ReturnType { return fin }
+    }
+
+    return res
+}
+
+// runBlock runs a block of a try expression in a scope of its own, exception is
+// pushed for the catch block to bind
+func (vm *VM) runBlock(f *frame, block int, exception object.Object) object.Object {
+    fn := f.cl.Constants[block].(*compiler.Function)
+    cl := &Closure{Fn: fn, Env: f.env, Constants: f.cl.Constants}
+
+    vm.pushFrame(cl, object.CreateEnclosedEnvironment(f.env), nil, nil, true, vm.sp)
+    if exception != nil { vm.push(exception) }
+
+    return vm.run(vm.fp - 1)
+}
+
+// matchVariant replaces the value and constructor on top of the stack by the result
+// of the match, a matched record with arguments to match leaves its values above it
+func (vm *VM) matchVariant(nargs int, name object.Object) object.Object {
+    ctor := vm.pop()
+    val := vm.pop()
+
+    def, ok := ctor.(*object.Struct)
+    if !ok {
+        if nargs != compiler.NoOperand { return createError(eval.InvalidPatternError, "%s is not a constructor", name) }
+
+        vm.push(boolean(object.Equal(ctor, val)))
+        return nil
+    }
+
+    rec, ok := val.(*object.Record)
+    if !ok || rec.Def != def {
+        vm.push(eval.False)
+        return nil
+    }
+
+    if nargs != compiler.NoOperand {
+        if nargs != len(def.Fields) {
+            return createError(eval.InvalidPatternError, "%s has %d fields, got %d", name, len(def.Fields), nargs)
+        }
+
+        for i := nargs - 1; i >= 0; i-- {
+            vm.push(rec.Values[i])
+        }
+    }
+
+    vm.push(eval.True)
+    return nil
+}
+
+func sortedKeys(named map[string]object.Object) []string {
+    if len(named) == 0 { return nil }
+    return slices.Sorted(maps.Keys(named))
+}
+
+func arityError(cl *Closure, got int) *object.Error {
+    required, total := 0, 0
+    for _, p := range cl.Fn.Params {
+        if p.Rest { continue }
+
+        total++
+        if !p.Default { required++ }
+    }
+
+    expected := fmt.Sprint(total)
+    if required != total { expected = fmt.Sprintf("%d to %d", required, total) }
+    if total != len(cl.Fn.Params) { expected = fmt.Sprintf("at least %d", required) }
+
+    return createError(eval.ArgumentMistmatchError, "%s expects %s, got %d", functionName(cl), expected, got)
+}
+
+func functionName(cl *Closure) string {
+    if cl.Name == "" { return "fn" }
+    return cl.Name
+}
+
+func createError(errKind string, msg string, args ...any) *object.Error {
+    return &object.Error{Kind: errKind, Message: errKind + ": " + fmt.Sprintf(msg, args...)}
+}
@@ -0,0 +1,85 @@
+package vm
+
+import (
+    "testing"
+
+    "lemur/compiler"
+    "lemur/eval"
+    "lemur/lexer"
+    "lemur/object"
+    "lemur/parser"
+)
+
+// the evaluator tests also run every program on the vm (see eval/vm_test.go), these
+// cover what is specific to running bytecode
+func TestRun(t *testing.T) {
+    tests := []struct{
+        input    string
+        expected string
+    }{
+        // frames and the stack grow past their initial size
+        {"let count = fn(n) { if (n == 0) { return 0 } 1 + count(n - 1) }; count(5000)", "5000"},
+        {"let sum = fn(...xs) { reduce(xs, fn(a, b) { a + b }, 0) }; sum(...(0..3000))", "4498500"},
+        // closures called back from builtins run nested
+        {"let k = 3; map([1, 2], fn(x) { let y = x * k; filter([y, 0], fn(v) { v > 0 }) })", "[[3], [6]]"},
+        {"let f = fn() { try { return 1 } finally { 2 } }; f() + 1", "2"},
+        {"let f = fn() { [1].map(fn(x) { throw x }) }; try { f() } catch e { e.line }", "1"},
+        {"return 5; 6", "5"},
+        {"let f = fn(a, b) { a }; [1].map(f)", "Error: wrong number of arguments for function: f expects 2, got 1 (line 1, col 32)"},
+        {"let f = fn(x) {\n    let y = x + \"s\"\n    y\n}\nf(1)", "Error: type mismatch: Integer + String (line 2, col 15)"},
+    }
+
+    for i, tst := range tests {
+        obj := runVM(t, tst.input, object.CreateEnvironment())
+        assert(t, i, obj.String(), tst.expected)
+
+        evaluated := eval.Eval(parser.New(lexer.New(tst.input)).ParseProgram(), object.CreateEnvironment())
+        assert(t, i, obj.String(), evaluated.String())
+    }
+}
+
+// the environment is shared between runs like REPL inputs
+func TestRunSharedEnvironment(t *testing.T) {
+    env := object.CreateEnvironment()
+    runVM(t, "const limit = 2; let f = fn(n) { n + limit }", env)
+
+    obj := runVM(t, "f(1)", env)
+    assert(t, 0, obj.String(), "3")
+
+    obj = runVM(t, "let limit = 3", env)
+    assert(t, 1, obj.String(), "Error: " + eval.ConstReassignmentError + ": limit (line 1, col 1)")
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+    input := "let fib = fn(n) { if (n < 2) { return n } fib(n - 1) + fib(n - 2) }; fib(20)"
+
+    b.Run("vm", func(b *testing.B) {
+        program := parser.New(lexer.New(input)).ParseProgram()
+        bc, err := compiler.Compile(program)
+        if err != nil { b.Fatal(err) }
+
+        for b.Loop() { New().Run(bc, object.CreateEnvironment()) }
+    })
+    b.Run("eval", func(b *testing.B) {
+        program := parser.New(lexer.New(input)).ParseProgram()
+
+        for b.Loop() { eval.Eval(program, object.CreateEnvironment()) }
+    })
+}
+
+func runVM(t *testing.T, input string, env *object.Environment) object.Object {
+    p := parser.New(lexer.New(input))
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0 { t.Fatalf("%q: %v", input, p.Errors()) }
+
+    return Run(program, env)
+}
+
+func assert(t *testing.T, testIdx int, val, expected any) {
+    if val != expected {
+        t.Errorf("test %d: incorrect value, expected %T: %v (got %T: %v)",
+            testIdx + 1,
+            expected, expected,
+            val, val)
+    }
+}